@@ -1,41 +1,199 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"subscription-service/config"
 	_ "subscription-service/docs"
-	"subscription-service/internal/handler"
+	"subscription-service/internal/cache"
+	"subscription-service/internal/currency"
+	"subscription-service/internal/currency/file"
+	"subscription-service/internal/notify"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/internal/repository/sub/cached"
+	"subscription-service/internal/repository/sub/memory"
 	"subscription-service/internal/repository/sub/postgres"
 	"subscription-service/internal/service"
-
-	"github.com/gorilla/mux"
-	httpSwagger "github.com/swaggo/http-swagger"
+	"subscription-service/internal/tlsconfig"
+	"subscription-service/internal/worker"
+	"sync"
+	"syscall"
+	"time"
 )
 
+// shutdownTimeout is how long requests in progress may take to finish on shutdown.
+const shutdownTimeout = 30 * time.Second
+
 // @title		Subscription Service API
 // @version		1.0
 // @description	REST service for aggregating data about users' online subscriptions
 // @BasePath	/
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				JWT as "Bearer <token>", required when AUTH_ENABLED is set
+//
+// @securityDefinitions.apikey	ApiKeyAuth
+// @in							header
+// @name						X-API-Key
+// @description				API key created with POST /apikeys, alternative to the bearer token
 func main() {
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		printConfig(os.Args[3:])
+		return
+	}
+
 	logger := log.New(os.Stdout, "[SubService] ", log.LstdFlags)
-	cfg := config.InitConfig(logger)
+	cfg, err := config.Load(logger, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		logger.Fatalf("Invalid configuration:\n%v", err)
+	}
 	addr := ":" + cfg.ServerPort
 
-	repo, err := postgres.NewSubPostgresRepository(cfg, logger)
+	// The workers stop and the server shuts down on SIGINT or SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var workers sync.WaitGroup
+
+	var repo sub.SubscriptionRepository
+	if cfg.Storage == config.StorageMemory {
+		logger.Println("Using in-memory storage, data is lost on exit")
+		repo = memory.NewSubMemoryRepository()
+	} else {
+		pgRepo, err := postgres.NewSubPostgresRepository(cfg, logger)
+		if err != nil {
+			logger.Fatalf("Database init error: %v", err)
+		}
+		repo = pgRepo
+	}
+	var repoCache *cache.LRU[cached.Entry]
+	if cfg.CacheEnabled {
+		repoCache = cache.New[cached.Entry](cfg.CacheSize, cfg.CacheTTL)
+		// Callers read from the primary for DB_READ_YOUR_WRITES after writing, the replica catches up by then.
+		var replicaLag time.Duration
+		if cfg.Storage != config.StorageMemory && cfg.DBReplicaURL != "" {
+			replicaLag = cfg.DBReadYourWrites
+		}
+		repo = cached.NewSubCachedRepository(repo, repoCache, replicaLag)
+	}
+	var rates currency.RateProvider
+	if cfg.RatesFile != "" {
+		provider, err := file.NewRateProvider(cfg.RatesFile)
+		if err != nil {
+			logger.Fatalf("Exchange rates init error: %v", err)
+		}
+		rates = provider
+	}
+
+	scope := service.Scope{
+		// The user role is limited to own subscriptions, so access control implies user isolation.
+		UserIsolation:    cfg.UserIsolation || cfg.RBACEnabled,
+		MultiTenant:      cfg.MultiTenant,
+		RowLevelSecurity: cfg.TenantRLS,
+	}
+	srv := service.NewSubService(repo, currency.NewConverter(rates), scope, service.OverlapPolicy(cfg.OverlapPolicy))
+	if cfg.RetentionPeriod > 0 && cfg.PurgeInterval > 0 {
+		purger := worker.NewPurger(srv, cfg.PurgeInterval, cfg.RetentionPeriod, logger)
+		workers.Go(func() { purger.Run(ctx) })
+	}
+
+	var notifier notify.Notifier = notify.NewLogNotifier(logger)
+	budgets := service.NewBudgetService(srv, notifier)
+	if cfg.BudgetInterval > 0 {
+		evaluator := worker.NewBudgetEvaluator(budgets, cfg.BudgetInterval, logger)
+		workers.Go(func() { evaluator.Run(ctx) })
+	}
+
+	webhooks := service.NewWebhookService(srv, service.DeliveryPolicy{
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		Backoff:      cfg.WebhookBackoff,
+		Timeout:      cfg.WebhookTimeout,
+		AllowPrivate: cfg.WebhookAllowPrivate,
+	})
+	if cfg.WebhookInterval > 0 {
+		dispatcher := worker.NewWebhookDispatcher(webhooks, cfg.WebhookInterval, logger)
+		workers.Go(func() { dispatcher.Run(ctx) })
+	}
+
+	root, err := newRouter(cfg, services{
+		subs:     srv,
+		catalog:  service.NewCatalogService(repo),
+		keys:     service.NewAPIKeyService(repo, scope),
+		budgets:  budgets,
+		webhooks: webhooks,
+		cache:    repoCache,
+	}, logger)
 	if err != nil {
-		logger.Fatalf("Database init error: %v", err)
+		logger.Fatalf("Authentication init error: %v", err)
 	}
-	srv := service.NewSubService(repo)
-	h := handler.NewSubHandler(srv, logger)
 
-	r := mux.NewRouter()
-	h.RegisterRoutes(r)
-	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	server := &http.Server{Addr: addr, Handler: root}
+	serve := server.ListenAndServe
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		certs, err := tlsconfig.NewReloader(tlsconfig.Config{
+			CertFile:     cfg.TLSCertFile,
+			KeyFile:      cfg.TLSKeyFile,
+			ClientCAFile: cfg.TLSClientCAFile,
+			ClientAuth:   cfg.TLSClientAuth,
+		}, logger)
+		if err != nil {
+			logger.Fatalf("TLS init error: %v", err)
+		}
+		if cfg.TLSReloadInterval > 0 {
+			workers.Go(func() { certs.Watch(ctx, cfg.TLSReloadInterval) })
+		}
+		server.TLSConfig = certs.TLSConfig()
+		serve = func() error { return server.ListenAndServeTLS("", "") }
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- serve() }()
+	if server.TLSConfig != nil {
+		logger.Println("Server starting with TLS at " + addr)
+	} else {
+		logger.Println("Server starting at " + addr)
+	}
 
-	logger.Println("Server starting at " + addr)
-	if err := http.ListenAndServe(addr, r); err != nil {
-		logger.Println("http server error:", err)
+	select {
+	case err := <-served:
+		logger.Println("Server error:", err)
+	case <-ctx.Done():
+		logger.Println("Shutting down")
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Println("Server shutdown error:", err)
+	}
+	workers.Wait()
+	logger.Println("Server stopped")
+}
+
+// printConfig writes the effective configuration with secrets redacted, followed by
+// any validation errors, so a broken configuration can be inspected.
+func printConfig(args []string) {
+	logger := log.New(os.Stderr, "[SubService] ", 0)
+	cfg, err := config.Load(logger, args)
+	if cfg != nil {
+		if err := cfg.Print(os.Stdout); err != nil {
+			logger.Fatalf("Print error: %v", err)
+		}
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		logger.Fatalf("Invalid configuration:\n%v", err)
 	}
 }
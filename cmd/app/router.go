@@ -0,0 +1,121 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"subscription-service/config"
+	"subscription-service/internal/auth"
+	"subscription-service/internal/cache"
+	"subscription-service/internal/handler"
+	"subscription-service/internal/middleware"
+	"subscription-service/internal/ratelimit"
+	"subscription-service/internal/repository/sub/cached"
+	"subscription-service/internal/service"
+
+	"github.com/gorilla/mux"
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+// services are the services behind the routes, cache is nil unless caching is enabled.
+type services struct {
+	subs     *service.SubService
+	catalog  *service.CatalogService
+	keys     *service.APIKeyService
+	budgets  *service.BudgetService
+	webhooks *service.WebhookService
+	cache    *cache.LRU[cached.Entry]
+}
+
+// newRouter registers the routes with the middleware enabled by the configuration,
+// guarding the services with the access policy if RBAC is enabled.
+func newRouter(cfg *config.Config, svc services, logger *log.Logger) (http.Handler, error) {
+	var subs service.SubscriptionService = svc.subs
+	var catalog service.CatalogManager = svc.catalog
+	var keyManager service.APIKeyManager = svc.keys
+	var budgetManager service.BudgetManager = svc.budgets
+	var webhookManager service.WebhookManager = svc.webhooks
+	var cacheMonitor service.CacheMonitor
+	if svc.cache != nil {
+		cacheMonitor = service.NewCacheService(svc.cache)
+	}
+	if cfg.RBACEnabled {
+		subs = service.NewPolicySubService(subs, service.DefaultPolicy)
+		catalog = service.NewPolicyCatalogService(catalog, service.DefaultPolicy)
+		keyManager = service.NewPolicyAPIKeyService(keyManager, service.DefaultPolicy)
+		budgetManager = service.NewPolicyBudgetService(budgetManager, service.DefaultPolicy)
+		webhookManager = service.NewPolicyWebhookService(webhookManager, service.DefaultPolicy)
+		if cacheMonitor != nil {
+			cacheMonitor = service.NewPolicyCacheService(cacheMonitor, service.DefaultPolicy)
+		}
+	}
+
+	h := handler.NewSubHandler(subs, logger)
+	ah := handler.NewAuditHandler(subs, logger)
+	ch := handler.NewCatalogHandler(catalog, logger)
+	bh := handler.NewBudgetHandler(budgetManager, logger)
+	wh := handler.NewWebhookHandler(webhookManager, logger)
+
+	r := mux.NewRouter()
+	r.Use(middleware.RequestContext, middleware.SecurityHeaders, middleware.MaxBodySize(cfg.MaxBodyBytes))
+	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+
+	api := r.PathPrefix("/").Subrouter()
+	api.Use(middleware.CacheControl(cfg.HTTPCacheMaxAge))
+	if cfg.RateLimitEnabled && cfg.AuthEnabled {
+		// A coarse limit per IP runs first, so that requests failing authentication are counted as well.
+		guard := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Limit(cfg.RateLimitIP), nil, ratelimit.ClientIP, logger)
+		api.Use(guard.Middleware)
+	}
+	if cfg.AuthEnabled {
+		authenticator, err := auth.NewAuthenticator(auth.Config{
+			HS256Secret: cfg.JWTHS256Secret,
+			JWKSFile:    cfg.JWTJWKSFile,
+			Issuer:      cfg.JWTIssuer,
+			Audience:    cfg.JWTAudience,
+			Leeway:      cfg.JWTLeeway,
+			APIKeys:     svc.keys,
+		})
+		if err != nil {
+			return nil, err
+		}
+		api.Use(authenticator.Middleware)
+	}
+	if cfg.RateLimitEnabled {
+		routes := make(map[string]ratelimit.Limit, len(cfg.RateLimitRoutes))
+		for route, limit := range cfg.RateLimitRoutes {
+			routes[route] = ratelimit.Limit(limit)
+		}
+		limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Limit(cfg.RateLimit), routes, ratelimit.Caller, logger)
+		api.Use(limiter.Middleware)
+	}
+	// Subscriptions, budgets, webhooks, API keys and the audit log belong to organizations, the catalog is shared.
+	tenantAPI := api.PathPrefix("/").Subrouter()
+	if cfg.MultiTenant {
+		tenantAPI.Use(middleware.Tenant(true))
+	}
+	h.RegisterRoutes(tenantAPI)
+	ah.RegisterRoutes(tenantAPI)
+	bh.RegisterRoutes(tenantAPI)
+	wh.RegisterRoutes(tenantAPI)
+	if cfg.AuthEnabled {
+		// API keys are only usable with authentication enabled.
+		handler.NewAPIKeyHandler(keyManager, logger).RegisterRoutes(tenantAPI)
+	}
+	ch.RegisterRoutes(api)
+	if cacheMonitor != nil {
+		handler.NewCacheHandler(cacheMonitor, logger).RegisterRoutes(api)
+	}
+
+	var root http.Handler = r
+	if len(cfg.CORSAllowedOrigins) > 0 {
+		root = middleware.CORS(middleware.CORSConfig{
+			AllowedOrigins:   cfg.CORSAllowedOrigins,
+			AllowedMethods:   cfg.CORSAllowedMethods,
+			AllowedHeaders:   cfg.CORSAllowedHeaders,
+			ExposedHeaders:   []string{middleware.HeaderRequestID, "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
+			AllowCredentials: cfg.CORSAllowCredentials,
+			MaxAge:           cfg.CORSMaxAge,
+		})(r)
+	}
+	return root, nil
+}
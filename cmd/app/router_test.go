@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"subscription-service/config"
+	"subscription-service/internal/auth"
+	"subscription-service/internal/cache"
+	"subscription-service/internal/currency"
+	"subscription-service/internal/repository/sub/cached"
+	"subscription-service/internal/repository/sub/memory"
+	"subscription-service/internal/service"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const testSecret = "router-test-secret"
+
+var testUser = uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+
+type routeCase struct {
+	method string
+	route  string
+	path   string
+	body   string
+	op     service.Operation
+}
+
+// routeCases has one request per route. Bodies are valid so that requests reach the policy,
+// IDs are unknown so that allowed requests get 404 instead of changing data.
+func routeCases() []routeCase {
+	id := uuid.New().String()
+	sub := fmt.Sprintf(`{"service_name":"Netflix","price":100,"user_id":%q,"start_date":"01-2025"}`, testUser)
+
+	return []routeCase{
+		{"POST", "/subscriptions", "/subscriptions", sub, service.OpCreateSubscription},
+		{"POST", "/subscriptions/batch", "/subscriptions/batch", "[" + sub + "]", service.OpCreateSubscription},
+		{"GET", "/subscriptions", "/subscriptions", "", service.OpListSubscriptions},
+		{"GET", "/subscription/{subID}", "/subscription/" + id, "", service.OpGetSubscription},
+		{"PUT", "/subscription/{subID}", "/subscription/" + id, sub, service.OpUpdateSubscription},
+		{"DELETE", "/subscription/{subID}", "/subscription/" + id, "", service.OpDeleteSubscription},
+		{"POST", "/subscription/{subID}/restore", "/subscription/" + id + "/restore", "", service.OpRestoreSubscription},
+		{"POST", "/subscription/{subID}/pause", "/subscription/" + id + "/pause", `{"effective_from":"02-2025"}`, service.OpPauseSubscription},
+		{"POST", "/subscription/{subID}/resume", "/subscription/" + id + "/resume", `{"effective_from":"03-2025"}`, service.OpResumeSubscription},
+		{"POST", "/subscription/{subID}/cancel", "/subscription/" + id + "/cancel", `{"effective_from":"03-2025"}`, service.OpCancelSubscription},
+		{"POST", "/subscription/{subID}/prices", "/subscription/" + id + "/prices", `{"price":200,"effective_from":"03-2025"}`, service.OpSchedulePrice},
+		{"GET", "/subscription/{subID}/prices", "/subscription/" + id + "/prices", "", service.OpGetPrices},
+		{"GET", "/subscriptions/total", "/subscriptions/total?start_date=01-2025&end_date=12-2025", "", service.OpGetTotal},
+		{"GET", "/subscriptions/forecast", "/subscriptions/forecast", "", service.OpGetForecast},
+		{"GET", "/audit", "/audit", "", service.OpGetAuditLog},
+		{"GET", "/subscription/{subID}/history", "/subscription/" + id + "/history", "", service.OpGetHistory},
+		{"POST", "/services", "/services", `{"name":"Netflix"}`, service.OpCreateService},
+		{"GET", "/services", "/services", "", service.OpListServices},
+		{"GET", "/service/{serviceID}", "/service/" + id, "", service.OpGetService},
+		{"PUT", "/service/{serviceID}", "/service/" + id, `{"name":"Netflix"}`, service.OpUpdateService},
+		{"DELETE", "/service/{serviceID}", "/service/" + id, "", service.OpDeleteService},
+		{"POST", "/apikeys", "/apikeys", `{"name":"batch","scopes":["user"]}`, service.OpCreateAPIKey},
+		{"GET", "/apikeys", "/apikeys", "", service.OpListAPIKeys},
+		{"DELETE", "/apikey/{keyID}", "/apikey/" + id, "", service.OpRevokeAPIKey},
+		{"POST", "/budgets", "/budgets", `{"name":"Streaming","amount":1000}`, service.OpCreateBudget},
+		{"GET", "/budgets", "/budgets", "", service.OpListBudgets},
+		{"GET", "/budget/{budgetID}", "/budget/" + id, "", service.OpGetBudget},
+		{"DELETE", "/budget/{budgetID}", "/budget/" + id, "", service.OpDeleteBudget},
+		{"GET", "/budgets/{budgetID}/status", "/budgets/" + id + "/status", "", service.OpGetBudgetStatus},
+		{"POST", "/webhooks", "/webhooks", `{"url":"https://203.0.113.10/hook","events":["subscription.created"]}`, service.OpCreateWebhook},
+		{"GET", "/webhooks", "/webhooks", "", service.OpListWebhooks},
+		{"GET", "/webhooks/dead-letters", "/webhooks/dead-letters", "", service.OpListDeadLetters},
+		{"POST", "/webhooks/deliveries/{deliveryID}/redeliver", "/webhooks/deliveries/" + id + "/redeliver", "", service.OpRedeliver},
+		{"GET", "/webhook/{webhookID}", "/webhook/" + id, "", service.OpGetWebhook},
+		{"DELETE", "/webhook/{webhookID}", "/webhook/" + id, "", service.OpDeleteWebhook},
+		{"GET", "/cache/stats", "/cache/stats", "", service.OpGetCacheStats},
+	}
+}
+
+func newTestRouter(t *testing.T, rbac bool) http.Handler {
+	t.Helper()
+	return newTestRouterConfig(t, &config.Config{
+		AuthEnabled:    true,
+		JWTHS256Secret: testSecret,
+		RBACEnabled:    rbac,
+		MaxBodyBytes:   1 << 20,
+	})
+}
+
+func newTestRouterConfig(t *testing.T, cfg *config.Config) http.Handler {
+	t.Helper()
+	lru := cache.New[cached.Entry](100, time.Minute)
+	repo := cached.NewSubCachedRepository(memory.NewSubMemoryRepository(), lru, 0)
+	scope := service.Scope{UserIsolation: cfg.RBACEnabled}
+	subs := service.NewSubService(repo, currency.NewConverter(nil), scope, service.OverlapAllow)
+
+	router, err := newRouter(cfg, services{
+		subs:     subs,
+		catalog:  service.NewCatalogService(repo),
+		keys:     service.NewAPIKeyService(repo, scope),
+		budgets:  service.NewBudgetService(subs, nil),
+		webhooks: service.NewWebhookService(subs, service.DeliveryPolicy{MaxAttempts: 1, Backoff: time.Second, Timeout: time.Second}),
+		cache:    lru,
+	}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("newRouter: %v", err)
+	}
+	return router
+}
+
+func testToken(t *testing.T, roles ...string) string {
+	t.Helper()
+	return subjectToken(t, testUser.String(), roles...)
+}
+
+func subjectToken(t *testing.T, subject string, roles ...string) string {
+	t.Helper()
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		Roles: roles,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func TestRoutesCovered(t *testing.T) {
+	covered := make(map[string]bool)
+	for _, c := range routeCases() {
+		covered[c.method+" "+c.route] = true
+	}
+
+	router := newTestRouter(t, true).(*mux.Router)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Prefixes and subrouters have no methods.
+			return nil
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			if !covered[method+" "+path] {
+				t.Errorf("route %s %s has no access test", method, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRouteAccess(t *testing.T) {
+	roles := []struct {
+		name  string
+		roles []string
+	}{
+		{"admin", []string{auth.RoleAdmin}},
+		{"user", []string{auth.RoleUser}},
+		{"auditor", []string{auth.RoleAuditor}},
+		{"anonymous", nil},
+	}
+
+	for _, rbac := range []bool{false, true} {
+		for _, role := range roles {
+			router := newTestRouter(t, rbac)
+			token := ""
+			if role.roles != nil {
+				token = testToken(t, role.roles...)
+			}
+
+			for _, c := range routeCases() {
+				name := fmt.Sprintf("rbac=%t/%s/%s %s", rbac, role.name, c.method, c.route)
+				t.Run(name, func(t *testing.T) {
+					req := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+					req.Header.Set("Content-Type", "application/json")
+					if token != "" {
+						req.Header.Set("Authorization", "Bearer "+token)
+					}
+					rec := httptest.NewRecorder()
+					router.ServeHTTP(rec, req)
+
+					switch {
+					case token == "":
+						if rec.Code != http.StatusUnauthorized {
+							t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+						}
+					case rbac && !service.DefaultPolicy.Allows(c.op, role.roles):
+						if rec.Code != http.StatusForbidden {
+							t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+						}
+					default:
+						if rec.Code == http.StatusUnauthorized || rec.Code == http.StatusForbidden {
+							t.Errorf("status = %d, want the operation allowed: %s", rec.Code, rec.Body)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestRateLimitCountsFailedAuthentication(t *testing.T) {
+	router := newTestRouterConfig(t, &config.Config{
+		AuthEnabled:      true,
+		JWTHS256Secret:   testSecret,
+		MaxBodyBytes:     1 << 20,
+		RateLimitEnabled: true,
+		RateLimit:        config.RateLimit{Rate: 0.001, Burst: 10},
+		RateLimitIP:      config.RateLimit{Rate: 0.001, Burst: 2},
+	})
+
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+	for i, status := range want {
+		req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+		req.Header.Set("Authorization", "Bearer invalid-"+strconv.Itoa(i))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Fatalf("request %d: status = %d, want %d", i+1, rec.Code, status)
+		}
+	}
+
+	// Another client has a bucket of its own.
+	req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+	req.RemoteAddr = "198.51.100.7:4242"
+	req.Header.Set("Authorization", "Bearer "+testToken(t, auth.RoleAdmin))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("other client: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimitPerCaller(t *testing.T) {
+	router := newTestRouterConfig(t, &config.Config{
+		AuthEnabled:      true,
+		JWTHS256Secret:   testSecret,
+		MaxBodyBytes:     1 << 20,
+		RateLimitEnabled: true,
+		RateLimit:        config.RateLimit{Rate: 0.001, Burst: 1},
+		RateLimitIP:      config.RateLimit{Rate: 0.001, Burst: 10},
+	})
+	get := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	first, second := testToken(t, auth.RoleAdmin), subjectToken(t, uuid.NewString(), auth.RoleAdmin)
+	// Callers behind the same address have buckets of their own.
+	for i, tt := range []struct {
+		token  string
+		status int
+	}{
+		{first, http.StatusOK},
+		{first, http.StatusTooManyRequests},
+		{second, http.StatusOK},
+		{second, http.StatusTooManyRequests},
+	} {
+		if got := get(tt.token); got != tt.status {
+			t.Errorf("request %d: status = %d, want %d", i+1, got, tt.status)
+		}
+	}
+}
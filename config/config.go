@@ -1,19 +1,39 @@
 package config
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// RateLimit allows Burst requests at once, refilled at Rate requests per second.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Storage backends.
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// Policies for subscriptions overlapping another one of the same user and service.
 const (
-	defaultDBHost     = "localhost"
-	defaultDBUser     = "postgres"
-	defaultDBPassword = ""
-	defaultDBName     = "sub_service"
-	defaultDBPort     = "5432"
-	defaultHTTPPort   = "8080"
+	OverlapAllow  = "allow"
+	OverlapWarn   = "warn"
+	OverlapReject = "reject"
+)
+
+// Notifiers delivering budget alerts.
+const (
+	NotifierLog = "log"
 )
 
 type Config struct {
@@ -23,25 +43,206 @@ type Config struct {
 	DBName     string
 	DBPort     string
 	ServerPort string
+	RatesFile  string
+	// Storage is "postgres" or "memory", which keeps data only while the process runs.
+	Storage string
+
+	// DatabaseURL replaces the separate DB_* connection settings, except the SSL ones
+	// and the password if it doesn't contain them.
+	DatabaseURL   string
+	DBSSLMode     string
+	DBSSLRootCert string
+	// DBMaxOpenConns of 0 means no limit, DBConnMaxLifetime of 0 keeps connections forever.
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	// DBConnectTimeout is how long startup waits for the database.
+	DBConnectTimeout time.Duration
+	// DBReplicaURL is a read replica for listing subscriptions and totals.
+	DBReplicaURL string
+	// DBReadYourWrites is how long reads of a caller go to the primary after it wrote.
+	DBReadYourWrites time.Duration
+
+	// OverlapPolicy decides if a subscription may overlap another one of the same user and service,
+	// OverlapConstraint also makes the database reject them.
+	OverlapPolicy     string
+	OverlapConstraint bool
+
+	PurgeInterval time.Duration
+	// RetentionPeriod is how long soft-deleted subscriptions are kept, 0 disables purging.
+	RetentionPeriod time.Duration
+
+	// BudgetInterval is how often budgets are evaluated, 0 disables alerts.
+	BudgetInterval time.Duration
+	// BudgetNotifier delivers the budget alerts.
+	BudgetNotifier string
+	// WebhookInterval is how often queued webhook deliveries are sent, 0 disables sending.
+	WebhookInterval time.Duration
+	// WebhookTimeout limits every webhook request.
+	WebhookTimeout time.Duration
+	// WebhookMaxAttempts is the number of attempts before a delivery is dead.
+	WebhookMaxAttempts int
+	// WebhookBackoff is the delay before the first retry, doubled for every further one.
+	WebhookBackoff time.Duration
+	// WebhookAllowPrivate allows webhooks to loopback, private and link-local addresses.
+	WebhookAllowPrivate bool
+
+	AuthEnabled    bool
+	JWTHS256Secret string
+	JWTJWKSFile    string
+	JWTIssuer      string
+	JWTAudience    string
+	JWTLeeway      time.Duration
+	// UserIsolation limits callers other than admins to their own subscriptions.
+	UserIsolation bool
+	// RBACEnabled checks the roles of callers against the access policy.
+	RBACEnabled bool
+	// MultiTenant limits every request to the data of its organization.
+	MultiTenant bool
+	// TenantRLS also sets the organization for the Postgres row-level security policies.
+	TenantRLS bool
+
+	RateLimitEnabled bool
+	// RateLimit limits every caller, identified by the API key or token subject.
+	RateLimit RateLimit
+	// RateLimitIP limits every client IP before authentication, counting requests with invalid credentials.
+	RateLimitIP RateLimit
+	// RateLimitRoutes overrides the limit of routes named "METHOD /path/template".
+	RateLimitRoutes map[string]RateLimit
+
+	// CORSAllowedOrigins enables CORS for the origins, "*" allows any origin.
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+	// MaxBodyBytes limits the size of request bodies.
+	MaxBodyBytes int64
+
+	// CacheEnabled caches subscriptions, lists and totals in memory for CacheTTL. With a replica,
+	// results aren't cached for DBReadYourWrites after a write, which must cover the replica lag.
+	CacheEnabled bool
+	CacheSize    int
+	CacheTTL     time.Duration
+	// HTTPCacheMaxAge is how long clients may keep responses, 0 makes them revalidate.
+	HTTPCacheMaxAge time.Duration
+
+	// TLSCertFile and TLSKeyFile enable HTTPS, the files are reloaded when changed.
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile verifies client certificates as set by TLSClientAuth.
+	TLSClientCAFile   string
+	TLSClientAuth     string
+	TLSReloadInterval time.Duration
+
+	// sources tells where every setting came from, for printing.
+	sources map[string]string
 }
 
-func InitConfig(logger *log.Logger) *Config {
+// Load builds the config from the defaults, overridden in turn by the config file,
+// the environment (including .env) and the command-line flags. The file is named
+// by the -config flag or CONFIG_FILE and may be YAML or TOML.
+//
+// Invalid values are reported together in the error. Unless the flags or the file
+// can't be read, the config is returned along with the error so it can be printed.
+func Load(logger *log.Logger, args []string) (*Config, error) {
 	if err := godotenv.Load(); err != nil {
-		logger.Println(".env not found, using default variables")
+		logger.Println(".env not found, using the environment")
 	}
-	return &Config{
-		DBHost:     getEnv("DB_HOST", defaultDBHost),
-		DBUser:     getEnv("DB_USER", defaultDBUser),
-		DBPassword: getEnv("DB_PASSWORD", defaultDBPassword),
-		DBName:     getEnv("DB_NAME", defaultDBName),
-		DBPort:     getEnv("DB_PORT", defaultDBPort),
-		ServerPort: getEnv("SERVER_PORT", defaultHTTPPort),
+
+	cfg := &Config{sources: make(map[string]string)}
+	settings := cfg.settings()
+
+	fs := flag.NewFlagSet("sub-service", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "config file, YAML or TOML")
+	for _, s := range settings {
+		_, isBool := s.value.(*boolValue)
+		fs.Var(&flagValue{val: s.def, isBool: isBool}, s.flagName(), s.usage)
 	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	flags := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) { flags[f.Name] = f.Value.String() })
+
+	var file map[string]string
+	if *configFile != "" {
+		var err error
+		if file, err = readFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[s.key] = true
+		val, source, err := s.lookup(flags, file)
+		if err != nil {
+			errs = append(errs, err)
+			val, source = s.def, "default"
+		}
+		if err := s.value.Set(val); err != nil {
+			errs = append(errs, fmt.Errorf("%s from %s: %w", s.key, source, err))
+			s.value.Set(s.def)
+			source = "default"
+		}
+		cfg.sources[s.key] = source
+	}
+	for key := range file {
+		if !known[key] {
+			errs = append(errs, fmt.Errorf("config file %s: unknown setting %q", *configFile, strings.ToLower(key)))
+		}
+	}
+
+	errs = append(errs, cfg.validate()...)
+	return cfg, errors.Join(errs...)
 }
 
-func getEnv(key, defaultValue string) string {
-	if val, ok := os.LookupEnv(key); ok {
-		return val
+// lookup returns the raw value of the setting from the layer with the highest precedence.
+func (s setting) lookup(flags, file map[string]string) (val, source string, err error) {
+	if val, ok := flags[s.flagName()]; ok {
+		return val, "flag -" + s.flagName(), nil
+	}
+	if s.secret() {
+		// Secrets are usually mounted as files, trailing newlines are dropped.
+		if path, ok := os.LookupEnv(s.key + "_FILE"); ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", "", fmt.Errorf("%s_FILE can't be read: %w", s.key, err)
+			}
+			return strings.TrimRight(string(data), "\r\n"), "env " + s.key + "_FILE", nil
+		}
+	}
+	if val, ok := os.LookupEnv(s.key); ok {
+		return val, "env " + s.key, nil
 	}
-	return defaultValue
+	if val, ok := file[s.key]; ok {
+		return val, "config file", nil
+	}
+	return s.def, "default", nil
+}
+
+// flagValue holds the raw flag value until the layers are merged,
+// flags of boolean settings may be given without a value.
+type flagValue struct {
+	val    string
+	isBool bool
+}
+
+func (f *flagValue) Set(s string) error {
+	f.val = s
+	return nil
 }
+
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.val
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
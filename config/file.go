@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile reads a flat YAML or TOML file, chosen by the extension, with settings named like
+// the env vars in lower case, e.g. "db_host: localhost". Lists may be given as arrays and
+// rate_limit_routes as a table of routes to "rate:burst".
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file can't be read: %w", err)
+	}
+
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		_, err = toml.Decode(string(data), &raw)
+	default:
+		return nil, fmt.Errorf("config file %s has unknown format, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, val := range raw {
+		values[strings.ToUpper(strings.ReplaceAll(key, "-", "_"))] = fileValue(val)
+	}
+	return values, nil
+}
+
+// fileValue formats a file value the way it would be set in the environment.
+func fileValue(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fileValue(item)
+		}
+		return strings.Join(items, ",")
+	case map[string]any:
+		entries := make([]string, 0, len(v))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			entries = append(entries, key+"="+fileValue(v[key]))
+		}
+		return strings.Join(entries, ";")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	want := map[string]string{
+		"DB_HOST":              "localhost",
+		"DB_MAX_OPEN_CONNS":    "20",
+		"AUTH_ENABLED":         "true",
+		"RATE_LIMIT_RATE":      "2.5",
+		"CORS_ALLOWED_ORIGINS": "https://a.example.com,https://b.example.com",
+		"RATE_LIMIT_ROUTES":    "GET /subscriptions=1:5;POST /subscriptions=0.5:2",
+		"CACHE_TTL":            "1m30s",
+		"TLS_CLIENT_AUTH":      "",
+	}
+	files := map[string]string{
+		"config.toml": `
+db_host = "localhost"
+db_max_open_conns = 20
+auth_enabled = true
+rate_limit_rate = 2.5
+cors_allowed_origins = ["https://a.example.com", "https://b.example.com"]
+cache_ttl = "1m30s"
+tls-client-auth = ""
+
+[rate_limit_routes]
+"GET /subscriptions" = "1:5"
+"POST /subscriptions" = "0.5:2"
+`,
+		"config.yaml": `
+db_host: localhost
+db_max_open_conns: 20
+auth_enabled: true
+rate_limit_rate: 2.5
+cors_allowed_origins: [https://a.example.com, https://b.example.com]
+cache_ttl: 1m30s
+tls-client-auth:
+rate_limit_routes:
+  GET /subscriptions: "1:5"
+  POST /subscriptions: "0.5:2"
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readFile(path)
+			if err != nil {
+				t.Fatalf("readFile: %v", err)
+			}
+			if !maps.Equal(got, want) {
+				t.Errorf("readFile = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"invalid.toml": "db_host = ",
+		"invalid.yaml": "db_host: [",
+		"config.json":  `{"db_host": "localhost"}`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readFile(path); err == nil {
+				t.Error("readFile succeeded")
+			}
+		})
+	}
+}
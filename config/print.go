@@ -0,0 +1,21 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Print writes the effective settings as env assignments along with their sources.
+// Secrets are redacted.
+func (c *Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range c.settings() {
+		val := s.value.String()
+		if s.secret() && val != "" {
+			val = s.redact(val)
+		}
+		fmt.Fprintf(tw, "%s=%s\t# %s\n", s.key, val, c.sources[s.key])
+	}
+	return tw.Flush()
+}
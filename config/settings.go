@@ -0,0 +1,259 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting is one config value, named KEY in the environment, -key as a flag and
+// key in the config file.
+type setting struct {
+	key   string
+	value value
+	def   string
+	usage string
+	// redact hides secrets when printing, which may also be read from the file named by KEY_FILE.
+	redact func(string) string
+}
+
+func (s setting) flagName() string {
+	return strings.ToLower(strings.ReplaceAll(s.key, "_", "-"))
+}
+
+func (s setting) secret() bool {
+	return s.redact != nil
+}
+
+// settings binds every setting to its field, in the order they are printed.
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "SERVER_PORT", value: (*stringValue)(&c.ServerPort), def: "8080", usage: "HTTP port"},
+		{key: "RATES_FILE", value: (*stringValue)(&c.RatesFile), usage: "exchange rates file"},
+		{key: "STORAGE", value: (*stringValue)(&c.Storage), def: StoragePostgres, usage: "storage backend, postgres or memory"},
+
+		{key: "DB_HOST", value: (*stringValue)(&c.DBHost), def: "localhost", usage: "database host"},
+		{key: "DB_PORT", value: (*stringValue)(&c.DBPort), def: "5432", usage: "database port"},
+		{key: "DB_USER", value: (*stringValue)(&c.DBUser), def: "postgres", usage: "database user"},
+		{key: "DB_PASSWORD", value: (*stringValue)(&c.DBPassword), usage: "database password", redact: redactSecret},
+		{key: "DB_NAME", value: (*stringValue)(&c.DBName), def: "sub_service", usage: "database name"},
+		{key: "DATABASE_URL", value: (*stringValue)(&c.DatabaseURL), usage: "database URL replacing DB_HOST, DB_PORT, DB_USER and DB_NAME", redact: redactURL},
+		{key: "DB_SSLMODE", value: (*stringValue)(&c.DBSSLMode), usage: "database SSL mode"},
+		{key: "DB_SSLROOTCERT", value: (*stringValue)(&c.DBSSLRootCert), usage: "CA bundle verifying the database server"},
+		{key: "DB_MAX_OPEN_CONNS", value: (*intValue)(&c.DBMaxOpenConns), def: "20", usage: "open database connections, 0 for no limit"},
+		{key: "DB_MAX_IDLE_CONNS", value: (*intValue)(&c.DBMaxIdleConns), def: "10", usage: "idle database connections"},
+		{key: "DB_CONN_MAX_LIFETIME", value: (*durationValue)(&c.DBConnMaxLifetime), def: "30m", usage: "database connection lifetime, 0 to keep forever"},
+		{key: "DB_CONNECT_TIMEOUT", value: (*durationValue)(&c.DBConnectTimeout), def: "1m", usage: "how long startup waits for the database"},
+		{key: "DB_REPLICA_URL", value: (*stringValue)(&c.DBReplicaURL), usage: "read replica URL for lists and totals", redact: redactURL},
+		{key: "DB_READ_YOUR_WRITES", value: (*durationValue)(&c.DBReadYourWrites), def: "5s", usage: "how long a caller reads from the primary after writing"},
+
+		{key: "OVERLAP_POLICY", value: (*stringValue)(&c.OverlapPolicy), def: OverlapAllow, usage: "overlapping subscriptions of a user and service, allow, warn or reject"},
+		{key: "OVERLAP_CONSTRAINT", value: (*boolValue)(&c.OverlapConstraint), def: "false", usage: "add a database constraint rejecting overlapping subscriptions"},
+
+		{key: "PURGE_INTERVAL", value: (*durationValue)(&c.PurgeInterval), def: "1h", usage: "interval of purging deleted subscriptions"},
+		{key: "RETENTION_PERIOD", value: (*durationValue)(&c.RetentionPeriod), def: "720h", usage: "how long deleted subscriptions are kept, 0 disables purging"},
+
+		{key: "BUDGET_INTERVAL", value: (*durationValue)(&c.BudgetInterval), def: "1h", usage: "interval of evaluating budgets, 0 disables alerts"},
+		{key: "BUDGET_NOTIFIER", value: (*stringValue)(&c.BudgetNotifier), def: NotifierLog, usage: "delivery of budget alerts, log"},
+
+		{key: "WEBHOOK_INTERVAL", value: (*durationValue)(&c.WebhookInterval), def: "10s", usage: "interval of sending webhook deliveries, 0 disables sending"},
+		{key: "WEBHOOK_TIMEOUT", value: (*durationValue)(&c.WebhookTimeout), def: "10s", usage: "timeout of webhook requests"},
+		{key: "WEBHOOK_MAX_ATTEMPTS", value: (*intValue)(&c.WebhookMaxAttempts), def: "8", usage: "attempts before a webhook delivery is dead"},
+		{key: "WEBHOOK_BACKOFF", value: (*durationValue)(&c.WebhookBackoff), def: "30s", usage: "delay before the first webhook retry, doubled for every further one"},
+		{key: "WEBHOOK_ALLOW_PRIVATE", value: (*boolValue)(&c.WebhookAllowPrivate), def: "false", usage: "allow webhooks to loopback, private and link-local addresses"},
+
+		{key: "AUTH_ENABLED", value: (*boolValue)(&c.AuthEnabled), def: "false", usage: "require a bearer token or API key"},
+		{key: "JWT_HS256_SECRET", value: (*stringValue)(&c.JWTHS256Secret), usage: "HS256 token secret", redact: redactSecret},
+		{key: "JWT_JWKS_FILE", value: (*stringValue)(&c.JWTJWKSFile), usage: "JWKS file with RS256 token keys"},
+		{key: "JWT_ISSUER", value: (*stringValue)(&c.JWTIssuer), usage: "required token issuer"},
+		{key: "JWT_AUDIENCE", value: (*stringValue)(&c.JWTAudience), usage: "required token audience"},
+		{key: "JWT_LEEWAY", value: (*durationValue)(&c.JWTLeeway), def: "30s", usage: "allowed clock skew for tokens"},
+		{key: "USER_ISOLATION", value: (*boolValue)(&c.UserIsolation), def: "false", usage: "limit callers to their own subscriptions"},
+		{key: "RBAC_ENABLED", value: (*boolValue)(&c.RBACEnabled), def: "false", usage: "check caller roles against the access policy"},
+		{key: "MULTI_TENANT", value: (*boolValue)(&c.MultiTenant), def: "false", usage: "limit requests to their organization"},
+		{key: "TENANT_RLS", value: (*boolValue)(&c.TenantRLS), def: "false", usage: "enforce organizations with row-level security"},
+
+		{key: "RATE_LIMIT_ENABLED", value: (*boolValue)(&c.RateLimitEnabled), def: "false", usage: "limit request rates"},
+		{key: "RATE_LIMIT_RATE", value: (*floatValue)(&c.RateLimit.Rate), def: "10", usage: "requests per second"},
+		{key: "RATE_LIMIT_BURST", value: (*intValue)(&c.RateLimit.Burst), def: "20", usage: "requests at once"},
+		{key: "RATE_LIMIT_IP_RATE", value: (*floatValue)(&c.RateLimitIP.Rate), def: "50", usage: "requests per second of a client IP before authentication"},
+		{key: "RATE_LIMIT_IP_BURST", value: (*intValue)(&c.RateLimitIP.Burst), def: "100", usage: "requests at once of a client IP before authentication"},
+		{key: "RATE_LIMIT_ROUTES", value: (*rateLimitsValue)(&c.RateLimitRoutes), usage: "route limits like 'GET /subscriptions/total=1:5;POST /subscriptions=5:10'"},
+
+		{key: "CORS_ALLOWED_ORIGINS", value: (*listValue)(&c.CORSAllowedOrigins), usage: "origins allowed by CORS, * for any"},
+		{key: "CORS_ALLOWED_METHODS", value: (*listValue)(&c.CORSAllowedMethods), def: "GET,POST,PUT,DELETE", usage: "methods allowed by CORS"},
+		{key: "CORS_ALLOWED_HEADERS", value: (*listValue)(&c.CORSAllowedHeaders), def: "Authorization,Content-Type,X-API-Key,X-Request-ID,X-Actor,X-Organization-ID,X-Read-Primary", usage: "headers allowed by CORS"},
+		{key: "CORS_ALLOW_CREDENTIALS", value: (*boolValue)(&c.CORSAllowCredentials), def: "false", usage: "allow credentials in CORS requests"},
+		{key: "CORS_MAX_AGE", value: (*durationValue)(&c.CORSMaxAge), def: "10m", usage: "how long preflight responses are cached"},
+		{key: "MAX_BODY_BYTES", value: (*int64Value)(&c.MaxBodyBytes), def: "1048576", usage: "request body size limit"},
+
+		{key: "CACHE_ENABLED", value: (*boolValue)(&c.CacheEnabled), def: "false", usage: "cache subscriptions, lists and totals in memory"},
+		{key: "CACHE_SIZE", value: (*intValue)(&c.CacheSize), def: "10000", usage: "cached results"},
+		{key: "CACHE_TTL", value: (*durationValue)(&c.CacheTTL), def: "30s", usage: "how long results are cached"},
+		{key: "HTTP_CACHE_MAX_AGE", value: (*durationValue)(&c.HTTPCacheMaxAge), def: "0s", usage: "how long clients may keep responses, 0 to revalidate"},
+
+		{key: "TLS_CERT_FILE", value: (*stringValue)(&c.TLSCertFile), usage: "TLS certificate"},
+		{key: "TLS_KEY_FILE", value: (*stringValue)(&c.TLSKeyFile), usage: "TLS private key"},
+		{key: "TLS_CLIENT_CA_FILE", value: (*stringValue)(&c.TLSClientCAFile), usage: "CA bundle verifying client certificates"},
+		{key: "TLS_CLIENT_AUTH", value: (*stringValue)(&c.TLSClientAuth), usage: "client certificates, verify_if_given or require"},
+		{key: "TLS_RELOAD_INTERVAL", value: (*durationValue)(&c.TLSReloadInterval), def: "30s", usage: "interval of checking the TLS files, 0 disables reloading"},
+	}
+}
+
+func redactSecret(val string) string {
+	return "<redacted>"
+}
+
+// dsnPassword matches the password of a keyword/value connection string, quoted or not.
+var dsnPassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)(?:'(?:[^'\\]|\\.)*'|\S*)`)
+
+// redactURL hides only the password of a database URL, given in the user info or as a query
+// parameter, or of a keyword/value connection string like "host=db password=secret".
+func redactURL(val string) string {
+	u, err := url.Parse(val)
+	if err != nil {
+		return redactSecret(val)
+	}
+	if u.Scheme == "" {
+		return dsnPassword.ReplaceAllString(val, "${1}xxxxx")
+	}
+	if q := u.Query(); q.Has("password") {
+		q.Set("password", "xxxxx")
+		u.RawQuery = q.Encode()
+	}
+	return u.Redacted()
+}
+
+// value parses a setting into its field and formats the field back.
+type value interface {
+	Set(string) error
+	String() string
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+func (v *stringValue) String() string { return string(*v) }
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("invalid boolean %q", s)
+	}
+	*v = boolValue(b)
+	return nil
+}
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", s)
+	}
+	*v = intValue(n)
+	return nil
+}
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+type int64Value int64
+
+func (v *int64Value) Set(s string) error {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", s)
+	}
+	*v = int64Value(n)
+	return nil
+}
+
+func (v *int64Value) String() string { return strconv.FormatInt(int64(*v), 10) }
+
+type floatValue float64
+
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", s)
+	}
+	*v = floatValue(f)
+	return nil
+}
+
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+
+// listValue is a comma-separated list, empty items are dropped.
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*v = list
+	return nil
+}
+
+func (v *listValue) String() string { return strings.Join(*v, ",") }
+
+// rateLimitsValue holds route limits like "GET /subscriptions/total=1:5;POST /subscriptions=5:10",
+// where the numbers are the rate per second and the burst.
+type rateLimitsValue map[string]RateLimit
+
+func (v *rateLimitsValue) Set(s string) error {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, limit, ok := strings.Cut(entry, "=")
+		rate, burst, hasBurst := strings.Cut(limit, ":")
+		r, rateErr := strconv.ParseFloat(rate, 64)
+		b, burstErr := strconv.Atoi(burst)
+		if !ok || !hasBurst || rateErr != nil || burstErr != nil || r <= 0 || b <= 0 {
+			return fmt.Errorf("invalid entry %q, expected 'METHOD /path=rate:burst'", entry)
+		}
+		limits[strings.Join(strings.Fields(route), " ")] = RateLimit{Rate: r, Burst: b}
+	}
+	*v = limits
+	return nil
+}
+
+func (v *rateLimitsValue) String() string {
+	entries := make([]string, 0, len(*v))
+	for _, route := range slices.Sorted(maps.Keys(*v)) {
+		limit := (*v)[route]
+		entries = append(entries, fmt.Sprintf("%s=%s:%d", route, strconv.FormatFloat(limit.Rate, 'g', -1, 64), limit.Burst))
+	}
+	return strings.Join(entries, ";")
+}
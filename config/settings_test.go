@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		name string
+		val  string
+		want string
+	}{
+		{"url", "postgres://app:secret@db:5432/subs?sslmode=verify-full", "postgres://app:xxxxx@db:5432/subs?sslmode=verify-full"},
+		{"url without password", "postgres://app@db/subs", "postgres://app@db/subs"},
+		{"query password", "postgres://db/subs?user=app&password=secret", "postgres://db/subs?password=xxxxx&user=app"},
+		{"keyword/value", "host=db user=app password=secret dbname=subs", "host=db user=app password=xxxxx dbname=subs"},
+		{"quoted keyword/value", "host=db password = 'se cr\\'et' dbname=subs", "host=db password = xxxxx dbname=subs"},
+		{"keyword/value without password", "host=db user=app", "host=db user=app"},
+		{"invalid url", "postgres://app:%zz@db/subs", "<redacted>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactURL(tt.val); got != tt.want {
+				t.Errorf("redactURL(%q) = %q, want %q", tt.val, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// sslModes are the modes supported by the Postgres driver, "" keeps its default.
+var sslModes = []string{"", "disable", "require", "verify-ca", "verify-full"}
+
+var storages = []string{StoragePostgres, StorageMemory}
+
+var overlapPolicies = []string{OverlapAllow, OverlapWarn, OverlapReject}
+
+var notifiers = []string{NotifierLog}
+
+var tlsClientAuths = []string{"", "verify_if_given", "require"}
+
+// validate checks the values and their combinations, returning every problem found.
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.ServerPort), "SERVER_PORT must be a port number, got %q", c.ServerPort)
+
+	check(slices.Contains(storages, c.Storage), "STORAGE must be postgres or memory, got %q", c.Storage)
+	// The database connection is only required by the postgres storage.
+	if c.Storage == StoragePostgres {
+		if c.DatabaseURL == "" {
+			check(c.DBHost != "", "DB_HOST is required")
+			check(validPort(c.DBPort), "DB_PORT must be a port number, got %q", c.DBPort)
+			check(c.DBUser != "", "DB_USER is required")
+			check(c.DBName != "", "DB_NAME is required")
+			check(c.DBPassword != "", "DB_PASSWORD is required without DATABASE_URL")
+		} else if _, err := url.Parse(c.DatabaseURL); err != nil {
+			// The error would contain the password.
+			errs = append(errs, errors.New("DATABASE_URL is not a valid URL"))
+		}
+	}
+	check(slices.Contains(sslModes, c.DBSSLMode), "DB_SSLMODE must be one of disable, require, verify-ca or verify-full, got %q", c.DBSSLMode)
+	check(c.DBMaxOpenConns >= 0, "DB_MAX_OPEN_CONNS must not be negative")
+	check(c.DBMaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	check(c.DBConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME must not be negative")
+	check(c.DBConnectTimeout >= 0, "DB_CONNECT_TIMEOUT must not be negative")
+	if _, err := url.Parse(c.DBReplicaURL); err != nil {
+		errs = append(errs, errors.New("DB_REPLICA_URL is not a valid URL"))
+	}
+	check(c.DBReadYourWrites >= 0, "DB_READ_YOUR_WRITES must not be negative")
+
+	check(slices.Contains(overlapPolicies, c.OverlapPolicy), "OVERLAP_POLICY must be allow, warn or reject, got %q", c.OverlapPolicy)
+	if c.OverlapConstraint {
+		check(c.OverlapPolicy == OverlapReject, "OVERLAP_CONSTRAINT requires OVERLAP_POLICY=reject")
+		check(c.Storage == StoragePostgres, "OVERLAP_CONSTRAINT requires the postgres STORAGE")
+	}
+
+	check(c.RetentionPeriod >= 0, "RETENTION_PERIOD must not be negative")
+	check(c.RetentionPeriod == 0 || c.PurgeInterval > 0, "PURGE_INTERVAL must be positive when RETENTION_PERIOD is set")
+
+	check(c.BudgetInterval >= 0, "BUDGET_INTERVAL must not be negative")
+	check(slices.Contains(notifiers, c.BudgetNotifier), "BUDGET_NOTIFIER must be log, got %q", c.BudgetNotifier)
+
+	check(c.WebhookInterval >= 0, "WEBHOOK_INTERVAL must not be negative")
+	check(c.WebhookTimeout > 0, "WEBHOOK_TIMEOUT must be positive")
+	check(c.WebhookMaxAttempts > 0, "WEBHOOK_MAX_ATTEMPTS must be positive")
+	check(c.WebhookBackoff > 0, "WEBHOOK_BACKOFF must be positive")
+
+	if c.AuthEnabled {
+		check(c.JWTHS256Secret != "" || c.JWTJWKSFile != "", "AUTH_ENABLED requires JWT_HS256_SECRET or JWT_JWKS_FILE")
+	}
+	check(c.JWTLeeway >= 0, "JWT_LEEWAY must not be negative")
+	check(!c.UserIsolation || c.AuthEnabled, "USER_ISOLATION requires AUTH_ENABLED")
+	check(!c.RBACEnabled || c.AuthEnabled, "RBAC_ENABLED requires AUTH_ENABLED")
+	check(!c.TenantRLS || c.MultiTenant, "TENANT_RLS requires MULTI_TENANT")
+	check(!c.TenantRLS || c.Storage == StoragePostgres, "TENANT_RLS requires the postgres STORAGE")
+
+	if c.RateLimitEnabled {
+		check(c.RateLimit.Rate > 0, "RATE_LIMIT_RATE must be positive")
+		check(c.RateLimit.Burst > 0, "RATE_LIMIT_BURST must be positive")
+		check(c.RateLimitIP.Rate > 0, "RATE_LIMIT_IP_RATE must be positive")
+		check(c.RateLimitIP.Burst > 0, "RATE_LIMIT_IP_BURST must be positive")
+	}
+
+	check(c.CORSMaxAge >= 0, "CORS_MAX_AGE must not be negative")
+	check(c.MaxBodyBytes > 0, "MAX_BODY_BYTES must be positive")
+
+	if c.CacheEnabled {
+		check(c.CacheSize > 0, "CACHE_SIZE must be positive")
+		check(c.CacheTTL > 0, "CACHE_TTL must be positive")
+	}
+	check(c.HTTPCacheMaxAge >= 0, "HTTP_CACHE_MAX_AGE must not be negative")
+
+	check((c.TLSCertFile == "") == (c.TLSKeyFile == ""), "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	check(slices.Contains(tlsClientAuths, c.TLSClientAuth), "TLS_CLIENT_AUTH must be verify_if_given or require, got %q", c.TLSClientAuth)
+	check(c.TLSClientAuth == "" || c.TLSClientCAFile != "", "TLS_CLIENT_AUTH requires TLS_CLIENT_CA_FILE")
+	check(c.TLSClientCAFile == "" || c.TLSCertFile != "", "TLS_CLIENT_CA_FILE requires TLS_CERT_FILE")
+	check(c.TLSReloadInterval >= 0, "TLS_RELOAD_INTERVAL must not be negative")
+
+	return errs
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
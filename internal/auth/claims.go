@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Roles recognized by the access policy.
+const (
+	RoleAdmin   = "admin"
+	RoleUser    = "user"
+	RoleAuditor = "auditor"
+)
+
+// APIKeySubject prefixes the ID of the API key in the subject of the claims it produces.
+const APIKeySubject = "apikey:"
+
+// Claims are the JWT claims the service relies on. Subject identifies the caller,
+// UserID is the user whose subscriptions the caller owns, the subject if not set.
+// OrganizationID is the tenant the caller belongs to.
+type Claims struct {
+	jwt.RegisteredClaims
+	UserID         string   `json:"user_id,omitempty"`
+	OrganizationID string   `json:"org_id,omitempty"`
+	Roles          []string `json:"roles,omitempty"`
+}
+
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// APIKeyID returns the ID of the API key the caller authenticated with.
+func (c *Claims) APIKeyID() (string, bool) {
+	return strings.CutPrefix(c.Subject, APIKeySubject)
+}
+
+// User returns the ID of the user the caller acts as.
+func (c *Claims) User() (uuid.UUID, error) {
+	if c.UserID != "" {
+		return uuid.Parse(c.UserID)
+	}
+	return uuid.Parse(c.Subject)
+}
+
+type ctxKey struct{}
+
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, ctxKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of an authenticated request.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(ctxKey{}).(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"subscription-service/internal/reqctx"
+	"subscription-service/pkg/utils"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	bearerPrefix = "Bearer "
+	apiKeyHeader = "X-API-Key"
+)
+
+var (
+	errUnknownKey = errors.New("unknown signing key")
+	// ErrInvalidAPIKey is returned by a KeyVerifier for unknown, revoked or expired keys.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+)
+
+// KeyVerifier checks API keys and returns the claims the key grants.
+type KeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*Claims, error)
+}
+
+type Config struct {
+	HS256Secret string
+	JWKSFile    string
+	Issuer      string
+	Audience    string
+	Leeway      time.Duration
+	// APIKeys enables the X-API-Key header if set.
+	APIKeys KeyVerifier
+}
+
+// Authenticator validates bearer JWTs signed with HS256 by a shared secret
+// or with RS256 by a key from the JWKS file.
+type Authenticator struct {
+	secret  []byte
+	rsaKeys map[string]*rsa.PublicKey
+	parser  *jwt.Parser
+	apiKeys KeyVerifier
+}
+
+func NewAuthenticator(cfg Config) (*Authenticator, error) {
+	a := &Authenticator{apiKeys: cfg.APIKeys}
+	var methods []string
+
+	if cfg.HS256Secret != "" {
+		a.secret = []byte(cfg.HS256Secret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.rsaKeys = keys
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("either HS256 secret or JWKS file is required")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+func (a *Authenticator) Parse(token string) (*Claims, error) {
+	claims := &Claims{}
+	if _, err := a.parser.ParseWithClaims(token, claims, a.key); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	return claims, nil
+}
+
+func (a *Authenticator) key(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return a.secret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := a.rsaKeys[kid]; ok {
+			return key, nil
+		}
+		if kid == "" && len(a.rsaKeys) == 1 {
+			for _, key := range a.rsaKeys {
+				return key, nil
+			}
+		}
+		return nil, errUnknownKey
+	}
+	return nil, jwt.ErrTokenSignatureInvalid
+}
+
+// Middleware rejects requests without a valid bearer token or API key with 401 and stores
+// the claims in the request context, using the subject as the audit actor.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(apiKeyHeader); key != "" && a.apiKeys != nil {
+			claims, err := a.apiKeys.VerifyAPIKey(r.Context(), key)
+			if err != nil {
+				if errors.Is(err, ErrInvalidAPIKey) {
+					unauthorized(w, err.Error())
+					return
+				}
+				utils.WriteProblem(w, http.StatusInternalServerError, "failed to verify API key")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withIdentity(r.Context(), claims)))
+			return
+		}
+
+		header := r.Header.Get("Authorization")
+		if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+			unauthorized(w, "missing bearer token")
+			return
+		}
+
+		claims, err := a.Parse(header[len(bearerPrefix):])
+		if err != nil {
+			unauthorized(w, "invalid bearer token: "+err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(withIdentity(r.Context(), claims)))
+	})
+}
+
+func withIdentity(ctx context.Context, claims *Claims) context.Context {
+	ctx = WithClaims(ctx, claims)
+	return reqctx.WithActor(ctx, claims.Subject)
+}
+
+func unauthorized(w http.ResponseWriter, detail string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	utils.WriteProblem(w, http.StatusUnauthorized, detail)
+}
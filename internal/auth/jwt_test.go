@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"subscription-service/internal/reqctx"
+	"subscription-service/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testSecret   = "auth-test-secret"
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "subscription-service"
+	testKID      = "key-1"
+)
+
+// writeJWKS writes the public key to a JWKS file and returns its path.
+func writeJWKS(t *testing.T, key *rsa.PublicKey) string {
+	t.Helper()
+	set := map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": testKID,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{RoleUser},
+	}
+}
+
+// with returns the valid claims changed by fn.
+func with(fn func(claims jwt.MapClaims)) jwt.MapClaims {
+	claims := validClaims()
+	fn(claims)
+	return claims
+}
+
+type fakeKeys map[string]*Claims
+
+func (k fakeKeys) VerifyAPIKey(_ context.Context, key string) (*Claims, error) {
+	if key == "sk_broken" {
+		return nil, errors.New("database down")
+	}
+	if claims, ok := k[key]; ok {
+		return claims, nil
+	}
+	return nil, ErrInvalidAPIKey
+}
+
+func TestMiddleware(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyClaims := &Claims{Roles: []string{RoleAdmin}}
+	keyClaims.Subject = APIKeySubject + "1"
+
+	authenticator, err := NewAuthenticator(Config{
+		HS256Secret: testSecret,
+		JWKSFile:    writeJWKS(t, &rsaKey.PublicKey),
+		Issuer:      testIssuer,
+		Audience:    testAudience,
+		Leeway:      time.Minute,
+		APIKeys:     fakeKeys{"sk_valid": keyClaims},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	hs256 := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + token
+	}
+	rs256 := func(key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + signed
+	}
+	signed := func(method jwt.SigningMethod, key any) string {
+		token, err := jwt.NewWithClaims(method, validClaims()).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + token
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		apiKey        string
+		status        int
+		subject       string
+	}{
+		{name: "valid HS256", authorization: hs256(validClaims()), status: http.StatusOK, subject: "60601fee-2bf1-4721-ae6f-7636e79a0cba"},
+		{name: "lowercase scheme", authorization: "bearer " + hs256(validClaims())[len("Bearer "):], status: http.StatusOK, subject: "60601fee-2bf1-4721-ae6f-7636e79a0cba"},
+		{name: "valid RS256 from JWKS", authorization: rs256(rsaKey, testKID, validClaims()), status: http.StatusOK, subject: "60601fee-2bf1-4721-ae6f-7636e79a0cba"},
+		{name: "RS256 without kid", authorization: rs256(rsaKey, "", validClaims()), status: http.StatusOK, subject: "60601fee-2bf1-4721-ae6f-7636e79a0cba"},
+		{name: "RS256 with unknown kid", authorization: rs256(rsaKey, "key-2", validClaims()), status: http.StatusUnauthorized},
+		{name: "RS256 signed by another key", authorization: rs256(otherKey, testKID, validClaims()), status: http.StatusUnauthorized},
+		{name: "HS256 with wrong secret", authorization: signed(jwt.SigningMethodHS256, []byte("other")), status: http.StatusUnauthorized},
+		{name: "HS512 algorithm", authorization: signed(jwt.SigningMethodHS512, []byte(testSecret)), status: http.StatusUnauthorized},
+		{name: "none algorithm", authorization: signed(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType), status: http.StatusUnauthorized},
+		{name: "expired", authorization: hs256(with(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-2 * time.Minute).Unix() })), status: http.StatusUnauthorized},
+		{name: "expired within leeway", authorization: hs256(with(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-30 * time.Second).Unix() })), status: http.StatusOK, subject: "60601fee-2bf1-4721-ae6f-7636e79a0cba"},
+		{name: "no expiration", authorization: hs256(with(func(c jwt.MapClaims) { delete(c, "exp") })), status: http.StatusUnauthorized},
+		{name: "wrong issuer", authorization: hs256(with(func(c jwt.MapClaims) { c["iss"] = "https://other.example.com" })), status: http.StatusUnauthorized},
+		{name: "wrong audience", authorization: hs256(with(func(c jwt.MapClaims) { c["aud"] = "other-service" })), status: http.StatusUnauthorized},
+		{name: "no subject", authorization: hs256(with(func(c jwt.MapClaims) { delete(c, "sub") })), status: http.StatusUnauthorized},
+		{name: "missing header", status: http.StatusUnauthorized},
+		{name: "basic scheme", authorization: "Basic dXNlcjpwYXNz", status: http.StatusUnauthorized},
+		{name: "empty bearer", authorization: "Bearer ", status: http.StatusUnauthorized},
+		{name: "malformed token", authorization: "Bearer not.a.jwt", status: http.StatusUnauthorized},
+		{name: "valid API key", apiKey: "sk_valid", status: http.StatusOK, subject: APIKeySubject + "1"},
+		{name: "invalid API key", apiKey: "sk_invalid", authorization: hs256(validClaims()), status: http.StatusUnauthorized},
+		{name: "API key verification error", apiKey: "sk_broken", status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims *Claims
+			var actor string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				claims, _ = ClaimsFromContext(r.Context())
+				actor = reqctx.Actor(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.apiKey != "" {
+				req.Header.Set(apiKeyHeader, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			authenticator.Middleware(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusOK {
+				if claims != nil {
+					t.Error("rejected request reached the handler")
+				}
+				return
+			}
+			if claims == nil || claims.Subject != tt.subject || actor != tt.subject {
+				t.Errorf("claims = %+v, actor = %q, want subject %q", claims, actor, tt.subject)
+			}
+		})
+	}
+}
+
+func TestUnauthorizedProblem(t *testing.T) {
+	authenticator, err := NewAuthenticator(Config{HS256Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	authenticator.Middleware(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if got := rec.Header().Get("WWW-Authenticate"); got != `Bearer error="invalid_token"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	var problem utils.Problem
+	if err = json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	want := utils.Problem{Type: "about:blank", Title: "Unauthorized", Status: http.StatusUnauthorized, Detail: "missing bearer token"}
+	if problem != want {
+		t.Errorf("problem = %+v, want %+v", problem, want)
+	}
+}
+
+func TestNewAuthenticatorErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"no keys", Config{}},
+		{"missing JWKS file", Config{JWKSFile: filepath.Join(dir, "missing.json")}},
+		{"invalid JWKS", Config{JWKSFile: write("invalid.json", "{")}},
+		{"no RSA keys", Config{JWKSFile: write("empty.json", `{"keys":[{"kty":"EC","kid":"ec"}]}`)}},
+		{"encryption key only", Config{JWKSFile: write("enc.json", `{"keys":[{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"}]}`)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAuthenticator(tt.cfg); err == nil {
+				t.Error("NewAuthenticator succeeded")
+			}
+		})
+	}
+}
+
+func TestClaimsUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  Claims
+		want    string
+		wantErr bool
+	}{
+		{name: "user_id claim", claims: Claims{UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", RegisteredClaims: jwt.RegisteredClaims{Subject: "svc"}}, want: "60601fee-2bf1-4721-ae6f-7636e79a0cba"},
+		{name: "subject", claims: Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "60601fee-2bf1-4721-ae6f-7636e79a0cba"}}, want: "60601fee-2bf1-4721-ae6f-7636e79a0cba"},
+		{name: "subject not a UUID", claims: Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "svc"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.claims.User()
+			if (err != nil) != tt.wantErr || (!tt.wantErr && got.String() != tt.want) {
+				t.Errorf("User() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
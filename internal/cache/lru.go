@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Stats counts cache lookups and removals since the cache was created.
+type Stats struct {
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRatio      float64 `json:"hit_ratio"`
+	Evictions     uint64  `json:"evictions"`
+	Expirations   uint64  `json:"expirations"`
+	Invalidations uint64  `json:"invalidations"`
+	Entries       int     `json:"entries"`
+	Capacity      int     `json:"capacity"`
+}
+
+// LRU keeps up to size values for the TTL, evicting the least recently used first.
+type LRU[V any] struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List
+	// version changes on every invalidation, see SetIfVersion.
+	version uint64
+	stats   Stats
+}
+
+type entry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+func New[V any](size int, ttl time.Duration) *LRU[V] {
+	return &LRU[V]{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+	}
+}
+
+func (c *LRU[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[V])
+		if c.now().Before(e.expires) {
+			c.order.MoveToFront(elem)
+			c.stats.Hits++
+			return e.value, true
+		}
+		c.remove(elem)
+		c.stats.Expirations++
+	}
+	c.stats.Misses++
+	var zero V
+	return zero, false
+}
+
+// Version returns the current version to pass to SetIfVersion.
+func (c *LRU[V]) Version() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version
+}
+
+// SetIfVersion stores the value unless the cache was invalidated since version was taken,
+// so a value loaded before a concurrent invalidation isn't cached.
+func (c *LRU[V]) SetIfVersion(key string, value V, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version != c.version {
+		return
+	}
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+	c.items[key] = c.order.PushFront(&entry[V]{key: key, value: value, expires: c.now().Add(c.ttl)})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// RemoveFunc removes the values fn returns true for and returns their number.
+func (c *LRU[V]) RemoveFunc(fn func(key string, value V) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	removed := 0
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if e := elem.Value.(*entry[V]); fn(e.key, e.value) {
+			c.remove(elem)
+			removed++
+		}
+		elem = next
+	}
+	c.stats.Invalidations += uint64(removed)
+	return removed
+}
+
+func (c *LRU[V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries, stats.Capacity = c.order.Len(), c.size
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
+func (c *LRU[V]) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry[V]).key)
+}
@@ -0,0 +1,41 @@
+package currency
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// RateProvider returns how many units of `to` one unit of `from` was worth in the given month.
+type RateProvider interface {
+	Rate(from, to string, month time.Time) (float64, error)
+}
+
+type Converter struct {
+	provider RateProvider
+}
+
+func NewConverter(provider RateProvider) *Converter {
+	return &Converter{provider: provider}
+}
+
+func (c *Converter) Convert(amount float64, from, to string, month time.Time) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+	if c.provider == nil {
+		return 0, ErrRateNotFound
+	}
+
+	rate, err := c.provider.Rate(from, to, month)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
+
+func Round(amount float64) int {
+	return int(math.Round(amount))
+}
@@ -0,0 +1,108 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"subscription-service/internal/currency"
+	"subscription-service/pkg/period"
+	"subscription-service/pkg/validator"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// ratesFile describes the JSON file with dated rates:
+//
+//	{"base": "RUB", "rates": [{"date": "2025-01-01", "currency": "USD", "rate": 0.0102}]}
+//
+// Rate is the amount of currency that one unit of base is worth starting from date.
+type ratesFile struct {
+	Base  string `json:"base"`
+	Rates []struct {
+		Date     string  `json:"date"`
+		Currency string  `json:"currency"`
+		Rate     float64 `json:"rate"`
+	} `json:"rates"`
+}
+
+type datedRate struct {
+	date time.Time
+	rate float64
+}
+
+type RateProvider struct {
+	base  string
+	rates map[string][]datedRate
+}
+
+func NewRateProvider(path string) (*RateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rates file: %w", err)
+	}
+
+	var f ratesFile
+	if err = json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse rates file: %w", err)
+	}
+	if f.Base == "" {
+		return nil, fmt.Errorf("rates file has no base currency")
+	}
+	// Codes are compared with the upper-cased currencies of subscriptions.
+	base := strings.ToUpper(f.Base)
+	if !validator.ValidateCurrency(base) {
+		return nil, fmt.Errorf("invalid base currency %q", f.Base)
+	}
+
+	rates := make(map[string][]datedRate)
+	for _, r := range f.Rates {
+		code := strings.ToUpper(r.Currency)
+		if !validator.ValidateCurrency(code) {
+			return nil, fmt.Errorf("invalid currency %q on %s", r.Currency, r.Date)
+		}
+		date, err := time.Parse(dateLayout, r.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate date %q: %w", r.Date, err)
+		}
+		if r.Rate <= 0 {
+			return nil, fmt.Errorf("rate for %s on %s must be positive", r.Currency, r.Date)
+		}
+		rates[code] = append(rates[code], datedRate{date: date, rate: r.Rate})
+	}
+	for _, list := range rates {
+		sort.Slice(list, func(i, j int) bool { return list[i].date.Before(list[j].date) })
+	}
+
+	return &RateProvider{base: base, rates: rates}, nil
+}
+
+func (p *RateProvider) Rate(from, to string, month time.Time) (float64, error) {
+	fromRate, err := p.baseRate(from, month)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := p.baseRate(to, month)
+	if err != nil {
+		return 0, err
+	}
+	return toRate / fromRate, nil
+}
+
+// baseRate returns the latest rate of currency against base published before the end of month.
+func (p *RateProvider) baseRate(code string, month time.Time) (float64, error) {
+	code = strings.ToUpper(code)
+	if code == p.base {
+		return 1, nil
+	}
+
+	monthEnd := month.AddDate(0, 1, 0)
+	list := p.rates[code]
+	i := sort.Search(len(list), func(i int) bool { return !list[i].date.Before(monthEnd) })
+	if i == 0 {
+		return 0, fmt.Errorf("%w: %s for %s", currency.ErrRateNotFound, code, period.Format(month))
+	}
+	return list[i-1].rate, nil
+}
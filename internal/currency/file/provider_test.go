@@ -0,0 +1,111 @@
+package file
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"subscription-service/internal/currency"
+	"testing"
+	"time"
+)
+
+func writeRates(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rates.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRate(t *testing.T) {
+	provider, err := NewRateProvider(writeRates(t, `{
+		"base": "RUB",
+		"rates": [
+			{"date": "2025-03-15", "currency": "USD", "rate": 0.012},
+			{"date": "2025-01-01", "currency": "USD", "rate": 0.010},
+			{"date": "2025-04-01", "currency": "USD", "rate": 0.011},
+			{"date": "2025-01-01", "currency": "EUR", "rate": 0.008}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("NewRateProvider: %v", err)
+	}
+
+	month := func(year int, m time.Month) time.Time { return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		from, to string
+		month    time.Time
+		want     float64
+		wantErr  error
+	}{
+		{name: "same currency", from: "RUB", to: "RUB", month: month(2020, time.January), want: 1},
+		{name: "to base", from: "USD", to: "RUB", month: month(2025, time.January), want: 100},
+		{name: "from base", from: "RUB", to: "USD", month: month(2025, time.January), want: 0.010},
+		{name: "rate published in a later month not used", from: "RUB", to: "USD", month: month(2025, time.February), want: 0.010},
+		{name: "latest rate before the end of the month", from: "RUB", to: "USD", month: month(2025, time.March), want: 0.012},
+		{name: "latest rate", from: "RUB", to: "USD", month: month(2026, time.June), want: 0.011},
+		{name: "cross rate", from: "USD", to: "EUR", month: month(2025, time.April), want: 0.008 / 0.011},
+		{name: "before the first rate", from: "RUB", to: "USD", month: month(2024, time.December), wantErr: currency.ErrRateNotFound},
+		{name: "unknown currency", from: "GBP", to: "RUB", month: month(2025, time.January), wantErr: currency.ErrRateNotFound},
+		{name: "cross rate missing one side", from: "USD", to: "GBP", month: month(2025, time.January), wantErr: currency.ErrRateNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.Rate(tt.from, tt.to, tt.month)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Rate: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("rate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateCaseInsensitive(t *testing.T) {
+	provider, err := NewRateProvider(writeRates(t, `{"base": "rub", "rates": [{"date": "2025-01-01", "currency": "usd", "rate": 0.01}]}`))
+	if err != nil {
+		t.Fatalf("NewRateProvider: %v", err)
+	}
+	month := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for _, pair := range [][2]string{{"RUB", "USD"}, {"rub", "usd"}} {
+		if got, err := provider.Rate(pair[0], pair[1], month); err != nil || got != 0.01 {
+			t.Errorf("Rate(%s, %s) = %v, %v, want 0.01", pair[0], pair[1], got, err)
+		}
+	}
+}
+
+func TestNewRateProviderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"invalid JSON", `{`},
+		{"no base", `{"rates": []}`},
+		{"invalid date", `{"base": "RUB", "rates": [{"date": "01-2025", "currency": "USD", "rate": 0.01}]}`},
+		{"zero rate", `{"base": "RUB", "rates": [{"date": "2025-01-01", "currency": "USD", "rate": 0}]}`},
+		{"negative rate", `{"base": "RUB", "rates": [{"date": "2025-01-01", "currency": "USD", "rate": -1}]}`},
+		{"invalid base", `{"base": "RUBL", "rates": []}`},
+		{"invalid currency", `{"base": "RUB", "rates": [{"date": "2025-01-01", "currency": "US$", "rate": 0.01}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRateProvider(writeRates(t, tt.content)); err == nil {
+				t.Error("NewRateProvider succeeded")
+			}
+		})
+	}
+
+	if _, err := NewRateProvider(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("NewRateProvider of a missing file succeeded")
+	}
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub/postgres"
+	"subscription-service/internal/service"
+	"subscription-service/pkg/utils"
+	"subscription-service/pkg/validator"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	paramKeyID         = "keyID"
+	errInvalidKeyID    = "invalid API key ID"
+	errAPIKeyNotFound  = "active API key not found"
+	errAPIKeyCollision = "API key collision, retry"
+)
+
+type APIKeyHandler struct {
+	srv    service.APIKeyManager
+	logger *log.Logger
+}
+
+func NewAPIKeyHandler(srv service.APIKeyManager, logger *log.Logger) *APIKeyHandler {
+	return &APIKeyHandler{srv: srv, logger: logger}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/apikeys", h.create).Methods("POST")
+	r.HandleFunc("/apikeys", h.getAll).Methods("GET")
+	r.HandleFunc("/apikey/{keyID}", h.revoke).Methods("DELETE")
+}
+
+// @Summary		Create API Key
+// @Description	Create an API key for the X-API-Key header. Scopes are the roles granted by the key.
+// @Description	The secret 'key' is only returned in this response
+// @Tags		API keys
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Accept		json
+// @Produce		json
+// @Param		key	body		model.APIKeyRequest	true	"API key payload"
+// @Success		201	{object}	model.NewAPIKey		"Created API key with secret"
+// @Failure		400	{object}	utils.ErrorResponse	"Validation error or invalid request body"
+// @Failure		401	{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403	{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		413	{object}	utils.ErrorResponse	"Request body is too large"
+// @Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/apikeys [post]
+func (h *APIKeyHandler) create(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("CREATE API key request")
+
+	var req model.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Println("CreateAPIKey: decode error:", err)
+		if isTooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, errDecodeMsg)
+		return
+	}
+
+	if validationErrs := validator.ValidateAPIKeyRequest(req); validationErrs != nil {
+		h.logger.Println("CreateAPIKey: validation error", validationErrs)
+		utils.WriteValidationErrors(w, validationErrs)
+		return
+	}
+
+	key, err := h.srv.Create(r.Context(), req)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrConflict) {
+			h.logger.Println("CreateAPIKey: conflict:", err)
+			utils.WriteError(w, http.StatusInternalServerError, errAPIKeyCollision)
+			return
+		}
+		h.logger.Println("Failed to create API key:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusCreated, key)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Get All API Keys
+// @Description	Get API keys ordered by creation time, including revoked ones. Secrets are never returned
+// @Tags		API keys
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Success		200	{array}		model.APIKey		"A list of API keys"
+// @Failure		401	{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403	{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/apikeys [get]
+func (h *APIKeyHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET all API keys request")
+
+	keys, err := h.srv.GetAll(r.Context())
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to get API keys:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, keys)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Revoke API Key
+// @Description	Revoke API key by ID, requests with the key are rejected afterwards
+// @Tags		API keys
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		keyID	path	string	true	"API key ID"	format(uuid)
+// @Success		204		"Successfully revoked API key"
+// @Failure		400		{object}	utils.ErrorResponse	"Invalid API key ID"
+// @Failure		401		{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		404		{object}	utils.ErrorResponse	"Active API key not found"
+// @Failure		500		{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/apikey/{keyID} [delete]
+func (h *APIKeyHandler) revoke(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("REVOKE API key request")
+
+	id, err := uuid.Parse(mux.Vars(r)[paramKeyID])
+	if err != nil {
+		h.logger.Println("Invalid API key ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidKeyID)
+		return
+	}
+
+	err = h.srv.Revoke(r.Context(), id)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("RevokeAPIKey: key not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errAPIKeyNotFound)
+			return
+		}
+		h.logger.Println("Failed to revoke API key:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"subscription-service/internal/model"
+	"subscription-service/internal/service"
+	"subscription-service/pkg/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+type AuditHandler struct {
+	srv    service.SubscriptionService
+	logger *log.Logger
+}
+
+func NewAuditHandler(srv service.SubscriptionService, logger *log.Logger) *AuditHandler {
+	return &AuditHandler{srv: srv, logger: logger}
+}
+
+func (h *AuditHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/audit", h.getAll).Methods("GET")
+	r.HandleFunc("/subscription/{subID}/history", h.history).Methods("GET")
+}
+
+// @Summary		Get Audit Log
+// @Description	Get audit entries of all subscription changes ordered by time. Optional filters for actor and time range
+// @Tags		Audit
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		actor	query		string				false	"Filter by actor"
+// @Param		from	query		string				false	"Changes made at or after this time (RFC 3339)"	format(date-time)
+// @Param		to		query		string				false	"Changes made at or before this time (RFC 3339)"	format(date-time)
+// @Param		limit	query		int					false	"Max number of entries, 100 by default"
+// @Param		offset	query		int					false	"Number of entries to skip"
+// @Success		200		{array}		model.AuditEntry	"Audit entries"
+// @Failure		400		{object}	utils.ErrorResponse	"Invalid parameters"
+// @Failure		401		{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		500		{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/audit [get]
+func (h *AuditHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET audit log request")
+
+	filter, errs := parseAuditFilter(r.URL.Query())
+	if errs != nil {
+		h.logger.Println("GetAudit: validation error", errs)
+		utils.WriteValidationErrors(w, errs)
+		return
+	}
+
+	entries, err := h.srv.GetAuditEntries(r.Context(), filter)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to get audit entries:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, entries)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Get Subscription History
+// @Description	Get audit entries of a subscription ordered by time, also available after deletion
+// @Tags		Audit
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		subID	path		string				true	"Subscription ID"	format(uuid)
+// @Param		actor	query		string				false	"Filter by actor"
+// @Param		from	query		string				false	"Changes made at or after this time (RFC 3339)"	format(date-time)
+// @Param		to		query		string				false	"Changes made at or before this time (RFC 3339)"	format(date-time)
+// @Param		limit	query		int					false	"Max number of entries, 100 by default"
+// @Param		offset	query		int					false	"Number of entries to skip"
+// @Success		200		{array}		model.AuditEntry	"Audit entries"
+// @Failure		400		{object}	utils.ErrorResponse	"Invalid subscription ID or parameters"
+// @Failure		401		{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		500		{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/subscription/{subID}/history [get]
+func (h *AuditHandler) history(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET subscription history request")
+
+	vars := mux.Vars(r)
+	subID := vars[paramSubID]
+
+	id, err := uuid.Parse(subID)
+	if err != nil {
+		h.logger.Println("Invalid subscription ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidID)
+		return
+	}
+
+	filter, errs := parseAuditFilter(r.URL.Query())
+	if errs != nil {
+		h.logger.Println("GetHistory: validation error", errs)
+		utils.WriteValidationErrors(w, errs)
+		return
+	}
+
+	entries, err := h.srv.GetHistory(r.Context(), id, filter)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to get subscription history:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, entries)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+func parseAuditFilter(params url.Values) (model.AuditFilter, []string) {
+	var errs []string
+	filter := model.AuditFilter{Actor: params.Get("actor")}
+
+	if from := params.Get("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			errs = append(errs, "from must be a RFC 3339 timestamp")
+		} else {
+			filter.From = &t
+		}
+	}
+
+	if to := params.Get("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			errs = append(errs, "to must be a RFC 3339 timestamp")
+		} else {
+			filter.To = &t
+		}
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			errs = append(errs, "limit must be a positive integer")
+		} else {
+			filter.Limit = n
+		}
+	}
+
+	if offset := params.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			errs = append(errs, "offset must be a non-negative integer")
+		} else {
+			filter.Offset = n
+		}
+	}
+
+	return filter, errs
+}
@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"subscription-service/internal/currency"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub/postgres"
+	"subscription-service/internal/service"
+	"subscription-service/pkg/period"
+	"subscription-service/pkg/utils"
+	"subscription-service/pkg/validator"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	paramBudgetID      = "budgetID"
+	errInvalidBudgetID = "invalid budget ID"
+	errBudgetNotFound  = "budget not found"
+	errBudgetMonth     = "month has invalid format, must be 'MM-YYYY'"
+)
+
+type BudgetHandler struct {
+	srv    service.BudgetManager
+	logger *log.Logger
+}
+
+func NewBudgetHandler(srv service.BudgetManager, logger *log.Logger) *BudgetHandler {
+	return &BudgetHandler{srv: srv, logger: logger}
+}
+
+func (h *BudgetHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/budgets", h.create).Methods("POST")
+	r.HandleFunc("/budgets", h.getAll).Methods("GET")
+	r.HandleFunc("/budget/{budgetID}", h.get).Methods("GET")
+	r.HandleFunc("/budget/{budgetID}", h.delete).Methods("DELETE")
+	r.HandleFunc("/budgets/{budgetID}/status", h.status).Methods("GET")
+}
+
+// @Summary		Create Budget
+// @Description	Add a monthly budget for the subscriptions of a user, of a category, of both or of all subscriptions.
+// @Description	An alert is sent when the spend of a month reaches one of the 'thresholds', percents of 'amount' (80 and 100 by default).
+// @Description	Callers limited to their own subscriptions can only budget their own spend
+// @Tags		Budgets
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Accept		json
+// @Produce		json
+// @Param		budget	body		model.BudgetRequest	true	"Budget payload"
+// @Success		201		{object}	model.Budget		"Successfully created budget"
+// @Failure		400		{object}	utils.ErrorResponse	"Validation error or invalid request body"
+// @Failure		401		{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		413		{object}	utils.ErrorResponse	"Request body is too large"
+// @Failure		500		{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/budgets [post]
+func (h *BudgetHandler) create(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("CREATE budget request")
+
+	var req model.BudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Println("CreateBudget: decode error:", err)
+		if isTooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, errDecodeMsg)
+		return
+	}
+
+	if validationErrs := validator.ValidateBudgetRequest(req); validationErrs != nil {
+		h.logger.Println("CreateBudget: validation error", validationErrs)
+		utils.WriteValidationErrors(w, validationErrs)
+		return
+	}
+
+	budget := model.Budget{
+		Name:       req.Name,
+		UserID:     req.UserID,
+		Category:   req.Category,
+		Amount:     req.Amount,
+		Currency:   req.Currency,
+		Thresholds: req.Thresholds,
+	}
+
+	if err := h.srv.Create(r.Context(), &budget); err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to create budget:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err := utils.WriteJSON(w, http.StatusCreated, budget)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Get All Budgets
+// @Description	Get the budgets visible to the caller ordered by creation time
+// @Tags		Budgets
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Success		200	{array}		model.Budget		"A list of budgets"
+// @Failure		401	{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403	{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/budgets [get]
+func (h *BudgetHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET all budgets request")
+
+	budgets, err := h.srv.GetAll(r.Context())
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to get budgets:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, budgets)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Get Budget
+// @Description	Get budget by ID
+// @Tags		Budgets
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		budgetID	path		string				true	"Budget ID"	format(uuid)
+// @Success		200			{object}	model.Budget		"Requested budget"
+// @Failure		400			{object}	utils.ErrorResponse	"Invalid budget ID"
+// @Failure		401			{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403			{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		404			{object}	utils.ErrorResponse	"Budget not found"
+// @Failure		500			{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/budget/{budgetID} [get]
+func (h *BudgetHandler) get(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET budget request")
+
+	id, err := uuid.Parse(mux.Vars(r)[paramBudgetID])
+	if err != nil {
+		h.logger.Println("Invalid budget ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidBudgetID)
+		return
+	}
+
+	budget, err := h.srv.GetByID(r.Context(), id)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("GetBudget: budget not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errBudgetNotFound)
+			return
+		}
+		h.logger.Println("Failed to get budget:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, budget)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Delete Budget
+// @Description	Delete budget by ID with its alert history
+// @Tags		Budgets
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		budgetID	path	string	true	"Budget ID"	format(uuid)
+// @Success		204			"Successfully deleted budget"
+// @Failure		400			{object}	utils.ErrorResponse	"Invalid budget ID"
+// @Failure		401			{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403			{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		404			{object}	utils.ErrorResponse	"Budget not found"
+// @Failure		500			{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/budget/{budgetID} [delete]
+func (h *BudgetHandler) delete(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("DELETE budget request")
+
+	id, err := uuid.Parse(mux.Vars(r)[paramBudgetID])
+	if err != nil {
+		h.logger.Println("Invalid budget ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidBudgetID)
+		return
+	}
+
+	err = h.srv.Delete(r.Context(), id)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("DeleteBudget: budget not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errBudgetNotFound)
+			return
+		}
+		h.logger.Println("Failed to delete budget:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary		Get Budget Status
+// @Description	Compare the spend of a month with the budget. The spend is computed like the total sum of that month
+// @Description	and converted to the budget currency
+// @Tags		Budgets
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		budgetID	path		string				true	"Budget ID"	format(uuid)
+// @Param		month		query		string				false	"Month (MM-YYYY), the current one by default"	Example("01-2025")
+// @Success		200			{object}	model.BudgetStatus	"Budget status"
+// @Failure		400			{object}	utils.ErrorResponse	"Invalid budget ID or month"
+// @Failure		401			{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403			{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		404			{object}	utils.ErrorResponse	"Budget not found"
+// @Failure		422			{object}	utils.ErrorResponse	"Exchange rate not available"
+// @Failure		500			{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/budgets/{budgetID}/status [get]
+func (h *BudgetHandler) status(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET budget status request")
+
+	id, err := uuid.Parse(mux.Vars(r)[paramBudgetID])
+	if err != nil {
+		h.logger.Println("Invalid budget ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidBudgetID)
+		return
+	}
+
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		month = period.Format(time.Now())
+	} else if !validator.ValidateMonthYear(month) {
+		h.logger.Println("month is incorrect:", month)
+		utils.WriteError(w, http.StatusBadRequest, errBudgetMonth)
+		return
+	}
+
+	status, err := h.srv.Status(r.Context(), id, month)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("BudgetStatus: budget not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errBudgetNotFound)
+			return
+		}
+		if errors.Is(err, currency.ErrRateNotFound) {
+			h.logger.Println("Failed to convert budget spend:", err)
+			utils.WriteError(w, http.StatusUnprocessableEntity, errRateNotFound)
+			return
+		}
+		h.logger.Println("Failed to get budget status:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, status)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
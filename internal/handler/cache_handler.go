@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"subscription-service/internal/service"
+	"subscription-service/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type CacheHandler struct {
+	srv    service.CacheMonitor
+	logger *log.Logger
+}
+
+func NewCacheHandler(srv service.CacheMonitor, logger *log.Logger) *CacheHandler {
+	return &CacheHandler{srv: srv, logger: logger}
+}
+
+func (h *CacheHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/cache/stats", h.stats).Methods("GET")
+}
+
+// @Summary		Get Cache Statistics
+// @Description	Get hits, misses and removals of the subscription cache since startup, available when CACHE_ENABLED is set
+// @Tags		Cache
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Success		200	{object}	cache.Stats			"Cache statistics"
+// @Failure		401	{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403	{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/cache/stats [get]
+func (h *CacheHandler) stats(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET cache stats request")
+
+	stats, err := h.srv.Stats(r.Context())
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to get cache stats:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, stats)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub/postgres"
+	"subscription-service/internal/service"
+	"subscription-service/pkg/utils"
+	"subscription-service/pkg/validator"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	paramServiceID      = "serviceID"
+	errInvalidServiceID = "invalid service ID"
+	errServiceNotFound  = "service not found"
+	errServiceConflict  = "service name or alias is already used"
+	errServiceInUse     = "service is used by subscriptions"
+)
+
+type CatalogHandler struct {
+	srv    service.CatalogManager
+	logger *log.Logger
+}
+
+func NewCatalogHandler(srv service.CatalogManager, logger *log.Logger) *CatalogHandler {
+	return &CatalogHandler{srv: srv, logger: logger}
+}
+
+func (h *CatalogHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/services", h.create).Methods("POST")
+	r.HandleFunc("/services", h.getAll).Methods("GET")
+	r.HandleFunc("/service/{serviceID}", h.get).Methods("GET")
+	r.HandleFunc("/service/{serviceID}", h.update).Methods("PUT")
+	r.HandleFunc("/service/{serviceID}", h.delete).Methods("DELETE")
+}
+
+// @Summary		Create Service
+// @Description	Add a service to the catalog. The name is always an alias of the service
+// @Tags		Services
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Accept		json
+// @Produce		json
+// @Param		service	body		model.ServiceRequest	true	"Service payload"
+// @Success		201		{object}	model.Service			"Successfully created service"
+// @Failure		400		{object}	utils.ErrorResponse		"Validation error or invalid request body"
+// @Failure		401		{object}	utils.Problem			"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse		"Operation not allowed for the caller"
+// @Failure		409		{object}	utils.ErrorResponse		"Name or alias is already used"
+// @Failure		413		{object}	utils.ErrorResponse		"Request body is too large"
+// @Failure		500		{object}	utils.ErrorResponse		"Internal server error"
+// @Router		/services [post]
+func (h *CatalogHandler) create(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("CREATE service request")
+
+	var req model.ServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Println("CreateService: decode error:", err)
+		if isTooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, errDecodeMsg)
+		return
+	}
+
+	if validationErrs := validator.ValidateServiceRequest(req); validationErrs != nil {
+		h.logger.Println("CreateService: validation error", validationErrs)
+		utils.WriteValidationErrors(w, validationErrs)
+		return
+	}
+
+	svc := model.Service{
+		Name:         req.Name,
+		Aliases:      req.Aliases,
+		Category:     req.Category,
+		DefaultPrice: req.DefaultPrice,
+	}
+
+	if err := h.srv.Create(r.Context(), &svc); err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrConflict) {
+			h.logger.Println("CreateService: conflict:", err)
+			utils.WriteError(w, http.StatusConflict, errServiceConflict)
+			return
+		}
+		h.logger.Println("Failed to create service:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err := utils.WriteJSON(w, http.StatusCreated, svc)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Get All Services
+// @Description	Get the service catalog ordered by name
+// @Tags		Services
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Success		200	{array}		model.Service		"A list of services"
+// @Failure		401	{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403	{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/services [get]
+func (h *CatalogHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET all services request")
+
+	services, err := h.srv.GetAll(r.Context())
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to get services:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, services)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Get Service
+// @Description	Get catalog service by ID
+// @Tags		Services
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		serviceID	path		string				true	"Service ID"	format(uuid)
+// @Success		200			{object}	model.Service		"Requested service"
+// @Failure		400			{object}	utils.ErrorResponse	"Invalid service ID"
+// @Failure		401			{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403			{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		404			{object}	utils.ErrorResponse	"Service not found"
+// @Failure		500			{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/service/{serviceID} [get]
+func (h *CatalogHandler) get(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET service request")
+
+	id, err := uuid.Parse(mux.Vars(r)[paramServiceID])
+	if err != nil {
+		h.logger.Println("Invalid service ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidServiceID)
+		return
+	}
+
+	svc, err := h.srv.GetByID(r.Context(), id)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("GetService: service not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errServiceNotFound)
+			return
+		}
+		h.logger.Println("Failed to get service:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, svc)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Update Service
+// @Description	Update catalog service by ID, replacing its aliases. Subscriptions of the service are renamed
+// @Tags		Services
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Accept		json
+// @Produce		json
+// @Param		serviceID	path		string					true	"Service ID"	format(uuid)
+// @Param		service		body		model.ServiceRequest	true	"Updated service payload"
+// @Success		200			{object}	model.Service			"Successfully updated service"
+// @Failure		400			{object}	utils.ErrorResponse		"Invalid service ID or validation error"
+// @Failure		401			{object}	utils.Problem			"Missing or invalid credentials"
+// @Failure		403			{object}	utils.ErrorResponse		"Operation not allowed for the caller"
+// @Failure		404			{object}	utils.ErrorResponse		"Service not found"
+// @Failure		409			{object}	utils.ErrorResponse		"Name or alias is already used"
+// @Failure		413			{object}	utils.ErrorResponse		"Request body is too large"
+// @Failure		500			{object}	utils.ErrorResponse		"Internal server error"
+// @Router		/service/{serviceID} [put]
+func (h *CatalogHandler) update(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("UPDATE service request")
+
+	id, err := uuid.Parse(mux.Vars(r)[paramServiceID])
+	if err != nil {
+		h.logger.Println("Invalid service ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidServiceID)
+		return
+	}
+
+	var req model.ServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Println("UpdateService: decode error:", err)
+		if isTooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, errDecodeMsg)
+		return
+	}
+
+	if validationErrs := validator.ValidateServiceRequest(req); validationErrs != nil {
+		h.logger.Println("UpdateService: validation error", validationErrs)
+		utils.WriteValidationErrors(w, validationErrs)
+		return
+	}
+
+	svc := model.Service{
+		Name:         req.Name,
+		Aliases:      req.Aliases,
+		Category:     req.Category,
+		DefaultPrice: req.DefaultPrice,
+	}
+
+	updated, err := h.srv.Update(r.Context(), id, &svc)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("UpdateService: service not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errServiceNotFound)
+			return
+		}
+		if errors.Is(err, postgres.ErrConflict) {
+			h.logger.Println("UpdateService: conflict:", err)
+			utils.WriteError(w, http.StatusConflict, errServiceConflict)
+			return
+		}
+		h.logger.Println("Failed to update service:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, updated)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Delete Service
+// @Description	Delete catalog service by ID. Services used by subscriptions can't be deleted
+// @Tags		Services
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		serviceID	path	string	true	"Service ID"	format(uuid)
+// @Success		204			"Successfully deleted service"
+// @Failure		400			{object}	utils.ErrorResponse	"Invalid service ID"
+// @Failure		401			{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403			{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		404			{object}	utils.ErrorResponse	"Service not found"
+// @Failure		409			{object}	utils.ErrorResponse	"Service is used by subscriptions"
+// @Failure		500			{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/service/{serviceID} [delete]
+func (h *CatalogHandler) delete(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("DELETE service request")
+
+	id, err := uuid.Parse(mux.Vars(r)[paramServiceID])
+	if err != nil {
+		h.logger.Println("Invalid service ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidServiceID)
+		return
+	}
+
+	err = h.srv.Delete(r.Context(), id)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("DeleteService: service not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errServiceNotFound)
+			return
+		}
+		if errors.Is(err, postgres.ErrConflict) {
+			h.logger.Println("DeleteService: service in use:", err)
+			utils.WriteError(w, http.StatusConflict, errServiceInUse)
+			return
+		}
+		h.logger.Println("Failed to delete service:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,11 +1,15 @@
 package handler
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"subscription-service/internal/currency"
 	"subscription-service/internal/model"
 	"subscription-service/internal/repository/sub/postgres"
 	"subscription-service/internal/service"
@@ -17,40 +21,74 @@ import (
 )
 
 const (
-	paramSubID     = "subID"
-	errDecodeMsg   = "decode JSON error"
-	errEncodeMsg   = "encode JSON error"
-	errInternalMsg = "internal error occurred"
-	errInvalidID   = "invalid subscription ID"
-	errNotFound    = "subscription not found"
+	defaultForecastMonths = 12
+	maxForecastMonths     = 60
 )
 
+const (
+	paramSubID         = "subID"
+	errDecodeMsg       = "decode JSON error"
+	errEncodeMsg       = "encode JSON error"
+	errInternalMsg     = "internal error occurred"
+	errInvalidID       = "invalid subscription ID"
+	errNotFound        = "subscription not found"
+	errRateNotFound    = "exchange rate not available"
+	errDeletedNotFound = "deleted subscription not found"
+	errIncludeDeleted  = "include_deleted must be a boolean"
+	errBodyTooLarge    = "request body is too large"
+	errEmptyBatch      = "at least one subscription is required"
+	errTrialEndsIn     = "trial_ends_in has invalid format, must be 'MM-YYYY'"
+	errForecastMonths  = "months must be a number from 1 to 60"
+)
+
+// OverlapResponse rejects a subscription overlapping others of the same user and service.
+type OverlapResponse struct {
+	Errors         []string    `json:"errors"`
+	ConflictingIDs []uuid.UUID `json:"conflicting_ids"`
+}
+
 type SubHandler struct {
-	srv    *service.SubService
+	srv    service.SubscriptionService
 	logger *log.Logger
 }
 
-func NewSubHandler(srv *service.SubService, logger *log.Logger) *SubHandler {
+func NewSubHandler(srv service.SubscriptionService, logger *log.Logger) *SubHandler {
 	return &SubHandler{srv: srv, logger: logger}
 }
 
 func (h *SubHandler) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/subscriptions", h.create).Methods("POST")
+	r.HandleFunc("/subscriptions/batch", h.createBatch).Methods("POST")
 	r.HandleFunc("/subscriptions", h.getAll).Methods("GET")
 	r.HandleFunc("/subscription/{subID}", h.get).Methods("GET")
 	r.HandleFunc("/subscription/{subID}", h.update).Methods("PUT")
 	r.HandleFunc("/subscription/{subID}", h.delete).Methods("DELETE")
+	r.HandleFunc("/subscription/{subID}/restore", h.restore).Methods("POST")
+	r.HandleFunc("/subscription/{subID}/pause", h.pause).Methods("POST")
+	r.HandleFunc("/subscription/{subID}/resume", h.resume).Methods("POST")
+	r.HandleFunc("/subscription/{subID}/cancel", h.cancel).Methods("POST")
+	r.HandleFunc("/subscription/{subID}/prices", h.schedulePriceChange).Methods("POST")
+	r.HandleFunc("/subscription/{subID}/prices", h.getPriceChanges).Methods("GET")
 	r.HandleFunc("/subscriptions/total", h.totalSum).Methods("GET")
+	r.HandleFunc("/subscriptions/forecast", h.forecast).Methods("GET")
 }
 
 // @Summary		Create Subscription
-// @Description	Create a new subscription record. Field 'end_data' is optional
+// @Description	Create a new subscription record. Field 'end_data' is optional. The service is referenced by 'service_id'
+// @Description	or by 'service_name' matched against catalog aliases, unknown names are added to the catalog.
+// @Description	Field 'price' may be omitted if the service has a default price
 // @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
 // @Accept		json
 // @Produce		json
 // @Param		subscription	body		model.SubRequest	true	"Subscription payload"
 // @Success		201				{object}	model.Subscription	"Successfully created subscription"
-// @Failure		400				{object}	utils.ErrorResponse	"Validation error or invalid request body"
+// @Failure		400				{object}	utils.ErrorResponse	"Validation error, invalid request body or unknown service"
+// @Failure		401				{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403				{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		413				{object}	utils.ErrorResponse	"Request body is too large"
+// @Failure		409				{object}	OverlapResponse		"Subscription overlaps another one of the same user and service"
 // @Failure		500				{object}	utils.ErrorResponse	"Internal server error"
 // @Router		/subscriptions [post]
 func (h *SubHandler) create(w http.ResponseWriter, r *http.Request) {
@@ -59,6 +97,10 @@ func (h *SubHandler) create(w http.ResponseWriter, r *http.Request) {
 	var req model.SubRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Println("Create: decode error:", err)
+		if isTooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, errDecodeMsg)
 		return
 	}
@@ -69,15 +111,24 @@ func (h *SubHandler) create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sub := model.Subscription{
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		UserID:      req.UserID,
-		StartDate:   req.StartDate,
-		EndDate:     req.EndDate,
-	}
+	sub := newSubscription(req)
 
-	if err := h.srv.Create(&sub); err != nil {
+	if err := h.srv.Create(r.Context(), sub); err != nil {
+		if errors.Is(err, service.ErrUnknownService) || errors.Is(err, service.ErrPriceRequired) {
+			h.logger.Println("Create: validation error", err)
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrOverlap) {
+			h.logger.Println("Overlapping subscription:", err)
+			writeOverlap(w, err)
+			return
+		}
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		h.logger.Println("Failed to create subscription:", err)
 		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
 		return
@@ -89,18 +140,135 @@ func (h *SubHandler) create(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// @Summary		Create Subscriptions
+// @Description	Create several subscriptions at once, either all of them are created or none.
+// @Description	Validation errors are prefixed with the index of the subscription
+// @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Accept		json
+// @Produce		json
+// @Param		subscriptions	body		[]model.SubRequest	true	"Subscription payloads"
+// @Success		201				{array}		model.Subscription	"Successfully created subscriptions"
+// @Failure		400				{object}	utils.ErrorResponse	"Validation error, invalid request body or unknown service"
+// @Failure		401				{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403				{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		413				{object}	utils.ErrorResponse	"Request body is too large"
+// @Failure		409				{object}	OverlapResponse		"Subscription overlaps another one of the same user and service"
+// @Failure		500				{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/subscriptions/batch [post]
+func (h *SubHandler) createBatch(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("CREATE subscriptions batch request")
+
+	var reqs []model.SubRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		h.logger.Println("CreateBatch: decode error:", err)
+		if isTooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, errDecodeMsg)
+		return
+	}
+
+	if len(reqs) == 0 {
+		h.logger.Println("CreateBatch: empty batch")
+		utils.WriteError(w, http.StatusBadRequest, errEmptyBatch)
+		return
+	}
+
+	var validationErrs []string
+	for i, req := range reqs {
+		for _, msg := range validator.ValidateSubRequest(req) {
+			validationErrs = append(validationErrs, fmt.Sprintf("[%d] %s", i, msg))
+		}
+	}
+	if validationErrs != nil {
+		h.logger.Println("CreateBatch: validation error", validationErrs)
+		utils.WriteValidationErrors(w, validationErrs)
+		return
+	}
+
+	subs := make([]*model.Subscription, len(reqs))
+	for i, req := range reqs {
+		subs[i] = newSubscription(req)
+	}
+
+	if err := h.srv.CreateBatch(r.Context(), subs); err != nil {
+		if errors.Is(err, service.ErrUnknownService) || errors.Is(err, service.ErrPriceRequired) {
+			h.logger.Println("CreateBatch: validation error", err)
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrOverlap) {
+			h.logger.Println("Overlapping subscription:", err)
+			writeOverlap(w, err)
+			return
+		}
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to create subscriptions:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err := utils.WriteJSON(w, http.StatusCreated, subs)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
 // @Summary		Get All Subscription
 // @Description	Get list of all subscriptions
 // @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
 // @Produce		json
-// @Success		200	{array}		model.Subscription	"A list of subscriptions"
-// @Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+// @Param		category		query		string				false	"Filter by category"
+// @Param		tag				query		[]string			false	"Filter by tags, subscriptions must have all of them"	collectionFormat(multi)
+// @Param		include_deleted	query		bool				false	"Include soft-deleted subscriptions"
+// @Param		trial_ends_in	query		string				false	"Filter by the last trial month, 'MM-YYYY'"
+// @Success		200				{array}		model.Subscription	"A list of subscriptions"
+// @Failure		400				{object}	utils.ErrorResponse	"Invalid parameters"
+// @Failure		401				{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403				{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		500				{object}	utils.ErrorResponse	"Internal server error"
 // @Router		/subscriptions [get]
 func (h *SubHandler) getAll(w http.ResponseWriter, r *http.Request) {
 	h.logger.Println("GET all subscriptions request")
 
-	subs, err := h.srv.GetAll()
+	params := r.URL.Query()
+	includeDeleted, err := parseBool(params.Get("include_deleted"))
+	if err != nil {
+		h.logger.Println("Invalid include_deleted:", err)
+		utils.WriteError(w, http.StatusBadRequest, errIncludeDeleted)
+		return
+	}
+
+	trialEndsIn := params.Get("trial_ends_in")
+	if trialEndsIn != "" && !validator.ValidateMonthYear(trialEndsIn) {
+		h.logger.Println("Invalid trial_ends_in:", trialEndsIn)
+		utils.WriteError(w, http.StatusBadRequest, errTrialEndsIn)
+		return
+	}
+
+	filter := model.ListFilter{
+		Category:       params.Get("category"),
+		Tags:           service.NormalizeTags(params["tag"]),
+		IncludeDeleted: includeDeleted,
+		TrialEndsIn:    trialEndsIn,
+	}
+
+	subs, err := h.srv.GetAll(r.Context(), filter)
 	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		h.logger.Println("Failed to get subscriptions:", err)
 		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
 		return
@@ -115,10 +283,14 @@ func (h *SubHandler) getAll(w http.ResponseWriter, r *http.Request) {
 // @Summary		Get Subscription
 // @Description	Get subscription by ID
 // @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
 // @Produce		json
 // @Param		subID	path		string				true	"Subscription ID"	format(uuid)
 // @Success		200		{object}	model.Subscription	"Requested subscription"
 // @Failure		400		{object}	utils.ErrorResponse	"Invalid subscription ID"
+// @Failure		401		{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse	"Operation not allowed for the caller"
 // @Failure		404		{object}	utils.ErrorResponse	"Subscription not found"
 // @Failure		500		{object}	utils.ErrorResponse	"Internal server error"
 // @Router		/subscription/{subID} [get]
@@ -135,13 +307,18 @@ func (h *SubHandler) get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sub, err := h.srv.GetByID(id)
+	sub, err := h.srv.GetByID(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, postgres.ErrNotFound) {
 			h.logger.Println("Get: subscription not found:", err)
 			utils.WriteError(w, http.StatusNotFound, errNotFound)
 			return
 		}
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		h.logger.Println("Failed to get subscription:", err)
 		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
 		return
@@ -156,13 +333,19 @@ func (h *SubHandler) get(w http.ResponseWriter, r *http.Request) {
 // @Summary		Update subscription
 // @Description	Update subscription by ID
 // @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
 // @Accept		json
 // @Produce		json
 // @Param		subID			path		string				true	"Subscription ID"	format(uuid)
 // @Param		subscription	body		model.SubRequest	true	"Updated subscription payload"
 // @Success		200				{object}	model.Subscription	"Successfully updated subscription"
 // @Failure		400				{object}	utils.ErrorResponse	"Invalid subscription ID or validation error"
+// @Failure		401				{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403				{object}	utils.ErrorResponse	"Operation not allowed for the caller"
 // @Failure		404				{object}	utils.ErrorResponse	"Subscription not found"
+// @Failure		413				{object}	utils.ErrorResponse	"Request body is too large"
+// @Failure		409				{object}	OverlapResponse		"Subscription overlaps another one of the same user and service"
 // @Failure		500				{object}	utils.ErrorResponse	"Internal server error"
 // @Router		/subscription/{subID} [put]
 func (h *SubHandler) update(w http.ResponseWriter, r *http.Request) {
@@ -181,6 +364,10 @@ func (h *SubHandler) update(w http.ResponseWriter, r *http.Request) {
 	var req model.SubRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.logger.Println("Create: decode error:", err)
+		if isTooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, errDecodeMsg)
 		return
 	}
@@ -191,21 +378,30 @@ func (h *SubHandler) update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sub := model.Subscription{
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		UserID:      req.UserID,
-		StartDate:   req.StartDate,
-		EndDate:     req.EndDate,
-	}
+	sub := newSubscription(req)
 
-	newSub, err := h.srv.Update(id, &sub)
+	newSub, err := h.srv.Update(r.Context(), id, sub)
 	if err != nil {
 		if errors.Is(err, postgres.ErrNotFound) {
 			h.logger.Println("Update error, subscription not found:", err)
 			utils.WriteError(w, http.StatusNotFound, errNotFound)
 			return
 		}
+		if errors.Is(err, service.ErrUnknownService) || errors.Is(err, service.ErrPriceRequired) {
+			h.logger.Println("Update: validation error", err)
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrOverlap) {
+			h.logger.Println("Overlapping subscription:", err)
+			writeOverlap(w, err)
+			return
+		}
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		h.logger.Println("Failed to update subscription:", err)
 		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
 		return
@@ -219,12 +415,16 @@ func (h *SubHandler) update(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary		Delete Subscription
-// @Description	Delete subscription by ID
+// @Description	Delete subscription by ID. It can be restored until purged after the retention period
 // @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
 // @Produce		json
 // @Param		subID	path	string	true	"Subscription ID"	format(uuid)
 // @Success		204		"Successfully deleted subscription"
 // @Failure		400		{object}	utils.ErrorResponse	"Invalid subscription ID"
+// @Failure		401		{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse	"Operation not allowed for the caller"
 // @Failure		404		{object}	utils.ErrorResponse	"Subscription not found"
 // @Failure		500		{object}	utils.ErrorResponse	"Internal server error"
 // @Router		/subscription/{subID} [delete]
@@ -241,29 +441,392 @@ func (h *SubHandler) delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.srv.Delete(id)
+	err = h.srv.Delete(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, postgres.ErrNotFound) {
 			h.logger.Println("Delete error, subscription not found:", err)
 			utils.WriteError(w, http.StatusNotFound, errNotFound)
 			return
 		}
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		h.logger.Println("Failed to delete subscription:", err)
 		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
 		return
 	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary		Restore Subscription
+// @Description	Restore a deleted subscription by ID
+// @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		subID	path		string				true	"Subscription ID"	format(uuid)
+// @Success		200		{object}	model.Subscription	"Successfully restored subscription"
+// @Failure		400		{object}	utils.ErrorResponse	"Invalid subscription ID"
+// @Failure		401		{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		404		{object}	utils.ErrorResponse	"Deleted subscription not found"
+// @Failure		409		{object}	OverlapResponse		"Subscription overlaps another one of the same user and service"
+// @Failure		500		{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/subscription/{subID}/restore [post]
+func (h *SubHandler) restore(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("RESTORE subscription request")
+
+	vars := mux.Vars(r)
+	subID := vars[paramSubID]
+
+	id, err := uuid.Parse(subID)
+	if err != nil {
+		h.logger.Println("Invalid subscription ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidID)
+		return
+	}
+
+	sub, err := h.srv.Restore(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("Restore error, deleted subscription not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errDeletedNotFound)
+			return
+		}
+		if errors.Is(err, postgres.ErrOverlap) {
+			h.logger.Println("Overlapping subscription:", err)
+			writeOverlap(w, err)
+			return
+		}
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to restore subscription:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, sub)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Pause Subscription
+// @Description	Pause charging a subscription from the 'effective_from' month through the 'until' month,
+// @Description	or until it is resumed if 'until' is omitted. Paused months are excluded from totals
+// @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Accept		json
+// @Produce		json
+// @Param		subID	path		string				true	"Subscription ID"	format(uuid)
+// @Param		pause	body		model.PauseRequest	true	"Pause payload"
+// @Success		200		{object}	model.Subscription	"Paused subscription"
+// @Failure		400		{object}	utils.ErrorResponse	"Invalid subscription ID, request body or month outside the subscription period"
+// @Failure		401		{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		404		{object}	utils.ErrorResponse	"Subscription not found"
+// @Failure		409		{object}	utils.ErrorResponse	"Subscription is already paused in that period"
+// @Failure		413		{object}	utils.ErrorResponse	"Request body is too large"
+// @Failure		500		{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/subscription/{subID}/pause [post]
+func (h *SubHandler) pause(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("PAUSE subscription request")
+
+	id, err := uuid.Parse(mux.Vars(r)[paramSubID])
+	if err != nil {
+		h.logger.Println("Invalid subscription ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidID)
+		return
+	}
+
+	var req model.PauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Println("Pause: decode error:", err)
+		if isTooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, errDecodeMsg)
+		return
+	}
+
+	if validationErrs := validator.ValidatePauseRequest(req); validationErrs != nil {
+		h.logger.Println("Pause: validation error", validationErrs)
+		utils.WriteValidationErrors(w, validationErrs)
+		return
+	}
+
+	sub, err := h.srv.Pause(r.Context(), id, &model.Pause{StartDate: req.EffectiveFrom, EndDate: req.Until})
+	h.writeLifecycle(w, "pause", sub, err)
+}
+
+// @Summary		Resume Subscription
+// @Description	Charge a paused subscription again from the 'effective_from' month, ending the pause covering it
+// @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Accept		json
+// @Produce		json
+// @Param		subID	path		string					true	"Subscription ID"	format(uuid)
+// @Param		resume	body		model.LifecycleRequest	true	"Resume payload"
+// @Success		200		{object}	model.Subscription		"Resumed subscription"
+// @Failure		400		{object}	utils.ErrorResponse		"Invalid subscription ID or request body"
+// @Failure		401		{object}	utils.Problem			"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse		"Operation not allowed for the caller"
+// @Failure		404		{object}	utils.ErrorResponse		"Subscription not found"
+// @Failure		409		{object}	utils.ErrorResponse		"Subscription is not paused in that month"
+// @Failure		413		{object}	utils.ErrorResponse		"Request body is too large"
+// @Failure		500		{object}	utils.ErrorResponse		"Internal server error"
+// @Router		/subscription/{subID}/resume [post]
+func (h *SubHandler) resume(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("RESUME subscription request")
+	h.changeLifecycle(w, r, "resume", h.srv.Resume)
+}
+
+// @Summary		Cancel Subscription
+// @Description	End a subscription before the 'effective_from' month, which must be after its start month
+// @Description	and not after its end month. The subscription is kept with the new end date
+// @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Accept		json
+// @Produce		json
+// @Param		subID	path		string					true	"Subscription ID"	format(uuid)
+// @Param		cancel	body		model.LifecycleRequest	true	"Cancel payload"
+// @Success		200		{object}	model.Subscription		"Cancelled subscription"
+// @Failure		400		{object}	utils.ErrorResponse		"Invalid subscription ID, request body or month outside the subscription period"
+// @Failure		401		{object}	utils.Problem			"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse		"Operation not allowed for the caller"
+// @Failure		404		{object}	utils.ErrorResponse		"Subscription not found"
+// @Failure		413		{object}	utils.ErrorResponse		"Request body is too large"
+// @Failure		500		{object}	utils.ErrorResponse		"Internal server error"
+// @Router		/subscription/{subID}/cancel [post]
+func (h *SubHandler) cancel(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("CANCEL subscription request")
+	h.changeLifecycle(w, r, "cancel", h.srv.Cancel)
+}
+
+// changeLifecycle decodes a lifecycle request and applies the action from its month.
+func (h *SubHandler) changeLifecycle(w http.ResponseWriter, r *http.Request, action string,
+	fn func(ctx context.Context, id uuid.UUID, effectiveFrom string) (*model.Subscription, error)) {
+	id, err := uuid.Parse(mux.Vars(r)[paramSubID])
+	if err != nil {
+		h.logger.Println("Invalid subscription ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidID)
+		return
+	}
+
+	var req model.LifecycleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Printf("%s: decode error: %v", action, err)
+		if isTooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, errDecodeMsg)
+		return
+	}
+
+	if validationErrs := validator.ValidateLifecycleRequest(req); validationErrs != nil {
+		h.logger.Printf("%s: validation error %v", action, validationErrs)
+		utils.WriteValidationErrors(w, validationErrs)
+		return
+	}
+
+	sub, err := fn(r.Context(), id, req.EffectiveFrom)
+	h.writeLifecycle(w, action, sub, err)
+}
+
+func (h *SubHandler) writeLifecycle(w http.ResponseWriter, action string, sub *model.Subscription, err error) {
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Printf("%s error, subscription not found: %v", action, err)
+			utils.WriteError(w, http.StatusNotFound, errNotFound)
+			return
+		}
+		if errors.Is(err, service.ErrLifecycleOutOfRange) {
+			h.logger.Printf("%s: validation error %v", action, err)
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrAlreadyPaused) || errors.Is(err, service.ErrNotPaused) {
+			h.logger.Printf("%s: conflict %v", action, err)
+			utils.WriteError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Printf("Failed to %s subscription: %v", action, err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	if err = utils.WriteJSON(w, http.StatusOK, sub); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Schedule Price Change
+// @Description	Set a new subscription price starting from the given month. Months before it keep the previous price
+// @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Accept		json
+// @Produce		json
+// @Param		subID			path		string						true	"Subscription ID"	format(uuid)
+// @Param		price_change	body		model.PriceChangeRequest	true	"Price change payload"
+// @Success		201				{object}	model.PriceChange			"Successfully scheduled price change"
+// @Failure		400				{object}	utils.ErrorResponse			"Invalid subscription ID or validation error"
+// @Failure		401				{object}	utils.Problem				"Missing or invalid credentials"
+// @Failure		403				{object}	utils.ErrorResponse			"Operation not allowed for the caller"
+// @Failure		404				{object}	utils.ErrorResponse			"Subscription not found"
+// @Failure		413				{object}	utils.ErrorResponse			"Request body is too large"
+// @Failure		500				{object}	utils.ErrorResponse			"Internal server error"
+// @Router		/subscription/{subID}/prices [post]
+func (h *SubHandler) schedulePriceChange(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("SCHEDULE price change request")
+
+	vars := mux.Vars(r)
+	subID := vars[paramSubID]
+
+	id, err := uuid.Parse(subID)
+	if err != nil {
+		h.logger.Println("Invalid subscription ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidID)
+		return
+	}
+
+	var req model.PriceChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Println("SchedulePriceChange: decode error:", err)
+		if isTooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, errDecodeMsg)
+		return
+	}
+
+	if validationErrs := validator.ValidatePriceChangeRequest(req); validationErrs != nil {
+		h.logger.Println("SchedulePriceChange: validation error", validationErrs)
+		utils.WriteValidationErrors(w, validationErrs)
+		return
+	}
+
+	change := model.PriceChange{
+		Price:         req.Price,
+		EffectiveFrom: req.EffectiveFrom,
+	}
+
+	if err := h.srv.SchedulePriceChange(r.Context(), id, &change); err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("SchedulePriceChange: subscription not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errNotFound)
+			return
+		}
+		if errors.Is(err, service.ErrPriceChangeOutOfRange) {
+			h.logger.Println("SchedulePriceChange: validation error", err)
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to schedule price change:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusCreated, change)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Get Price History
+// @Description	Get scheduled and past price changes of a subscription ordered by month
+// @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		subID	path		string				true	"Subscription ID"	format(uuid)
+// @Success		200		{array}		model.PriceChange	"Price changes"
+// @Failure		400		{object}	utils.ErrorResponse	"Invalid subscription ID"
+// @Failure		401		{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		404		{object}	utils.ErrorResponse	"Subscription not found"
+// @Failure		500		{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/subscription/{subID}/prices [get]
+func (h *SubHandler) getPriceChanges(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET price changes request")
+
+	vars := mux.Vars(r)
+	subID := vars[paramSubID]
+
+	id, err := uuid.Parse(subID)
+	if err != nil {
+		h.logger.Println("Invalid subscription ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidID)
+		return
+	}
+
+	changes, err := h.srv.GetPriceChanges(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("GetPriceChanges: subscription not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errNotFound)
+			return
+		}
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to get price changes:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, changes)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
 }
 
 // @Summary		Calculate Total Sum
-// @Description	Get the total cost of subscriptions for a given period. Optional filters for user and subscription name
+// @Description	Get the total cost of subscriptions for a given period. Every active month is charged at the price in effect and converted
+// @Description	to the target currency at the rate of that month. Optional filters for user, subscription name, category and tags.
+// @Description	With 'group_by' the response also has the total of every category or tag
 // @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
 // @Produce		json
 // @Param		start_date		query		string				true	"Start date of the period (MM-YYYY)"	Example("01-2025")
 // @Param		end_date		query		string				true	"End date of the period (MM-YYYY)"		Example("12-2025")
 // @Param		user_id			query		string				false	"Filter by User ID (UUID)"				format(uuid)
-// @Param		service_name	query		string				false	"Filter by subscription name"
-// @Success		200				{object}	map[string]int		"Total sum"
+// @Param		service_name	query		string				false	"Filter by service name or alias"
+// @Param		currency		query		string				false	"Target currency (ISO 4217), RUB by default"	Example("USD")
+// @Param		category		query		string				false	"Filter by category"
+// @Param		tag				query		[]string			false	"Filter by tags, subscriptions must have all of them"	collectionFormat(multi)
+// @Param		include_deleted	query		bool				false	"Include soft-deleted subscriptions"
+// @Param		group_by		query		string				false	"Also sum per category or per tag"	Enums(category, tag)
+// @Success		200				{object}	model.TotalSum		"Total sum"
 // @Failure		400				{object}	utils.ErrorResponse	"Invalid parameters"
+// @Failure		401				{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403				{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		422				{object}	utils.ErrorResponse	"Exchange rate not available"
 // @Failure		500				{object}	utils.ErrorResponse	"Internal server error"
 // @Router		/subscriptions/total [get]
 func (h *SubHandler) totalSum(w http.ResponseWriter, r *http.Request) {
@@ -274,6 +837,7 @@ func (h *SubHandler) totalSum(w http.ResponseWriter, r *http.Request) {
 	endDate := params.Get("end_date")
 	userID := params.Get("user_id")
 	name := params.Get("service_name")
+	target := strings.ToUpper(params.Get("currency"))
 
 	var id uuid.UUID
 	if userID != "" {
@@ -298,16 +862,192 @@ func (h *SubHandler) totalSum(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sum, err := h.srv.GetTotalSum(startDate, endDate, id, name)
+	if target != "" && !validator.ValidateCurrency(target) {
+		h.logger.Println("currency is incorrect:", target)
+		utils.WriteError(w, http.StatusBadRequest, "currency must be a 3-letter ISO 4217 code")
+		return
+	}
+
+	includeDeleted, err := parseBool(params.Get("include_deleted"))
+	if err != nil {
+		h.logger.Println("Invalid include_deleted:", err)
+		utils.WriteError(w, http.StatusBadRequest, errIncludeDeleted)
+		return
+	}
+
+	groupBy := params.Get("group_by")
+	if groupBy != "" && groupBy != model.GroupByCategory && groupBy != model.GroupByTag {
+		h.logger.Println("group_by is incorrect:", groupBy)
+		utils.WriteError(w, http.StatusBadRequest, "group_by must be 'category' or 'tag'")
+		return
+	}
+
+	filter := model.TotalFilter{
+		StartDate:      startDate,
+		EndDate:        endDate,
+		UserID:         id,
+		ServiceName:    name,
+		Category:       params.Get("category"),
+		Tags:           params["tag"],
+		IncludeDeleted: includeDeleted,
+		GroupBy:        groupBy,
+	}
+
+	sum, err := h.srv.GetTotalSum(r.Context(), filter, target)
 	if err != nil {
+		if errors.Is(err, currency.ErrRateNotFound) {
+			h.logger.Println("Failed to convert total sum:", err)
+			utils.WriteError(w, http.StatusUnprocessableEntity, errRateNotFound)
+			return
+		}
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		h.logger.Println("Failed to get total sum:", err)
 		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
 		return
 	}
 
-	err = utils.WriteJSON(w, http.StatusOK, map[string]int{"total_sum": sum})
+	err = utils.WriteJSON(w, http.StatusOK, sum)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+		return
+	}
+}
+
+// @Summary		Forecast Spend
+// @Description	Project the monthly spend of the coming months, starting with the current one, from the subscriptions
+// @Description	that aren't deleted. Subscriptions are charged in the months due by their billing interval, open-ended ones
+// @Description	keep being charged, ended ones stop, and scheduled price changes, trials, promos and pauses apply in their months. Charges are converted at the latest known rates
+// @Tags		Subscriptions
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		months			query		int					false	"Number of months, 12 by default"	minimum(1)	maximum(60)
+// @Param		user_id			query		string				false	"Filter by User ID (UUID)"			format(uuid)
+// @Param		service_name	query		string				false	"Filter by service name or alias"
+// @Param		currency		query		string				false	"Target currency (ISO 4217), RUB by default"	Example("USD")
+// @Param		category		query		string				false	"Filter by category"
+// @Param		tag				query		[]string			false	"Filter by tags, subscriptions must have all of them"	collectionFormat(multi)
+// @Success		200				{object}	model.Forecast		"Spend forecast"
+// @Failure		400				{object}	utils.ErrorResponse	"Invalid parameters"
+// @Failure		401				{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403				{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		422				{object}	utils.ErrorResponse	"Exchange rate not available"
+// @Failure		500				{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/subscriptions/forecast [get]
+func (h *SubHandler) forecast(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET spend forecast request")
+
+	params := r.URL.Query()
+	target := strings.ToUpper(params.Get("currency"))
+
+	months := defaultForecastMonths
+	if value := params.Get("months"); value != "" {
+		var err error
+		months, err = strconv.Atoi(value)
+		if err != nil || months < 1 || months > maxForecastMonths {
+			h.logger.Println("months is incorrect:", value)
+			utils.WriteError(w, http.StatusBadRequest, errForecastMonths)
+			return
+		}
+	}
+
+	var id uuid.UUID
+	if userID := params.Get("user_id"); userID != "" {
+		var err error
+		id, err = uuid.Parse(userID)
+		if err != nil {
+			h.logger.Println("Invalid user ID:", err)
+			utils.WriteError(w, http.StatusBadRequest, errInvalidID)
+			return
+		}
+	}
+
+	if target != "" && !validator.ValidateCurrency(target) {
+		h.logger.Println("currency is incorrect:", target)
+		utils.WriteError(w, http.StatusBadRequest, "currency must be a 3-letter ISO 4217 code")
+		return
+	}
+
+	filter := model.TotalFilter{
+		UserID:      id,
+		ServiceName: params.Get("service_name"),
+		Category:    params.Get("category"),
+		Tags:        params["tag"],
+	}
+
+	forecast, err := h.srv.GetForecast(r.Context(), months, filter, target)
+	if err != nil {
+		if errors.Is(err, currency.ErrRateNotFound) {
+			h.logger.Println("Failed to convert forecast:", err)
+			utils.WriteError(w, http.StatusUnprocessableEntity, errRateNotFound)
+			return
+		}
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to get forecast:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, forecast)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
 		return
 	}
 }
+
+func newSubscription(req model.SubRequest) *model.Subscription {
+	return &model.Subscription{
+		ServiceID:       req.ServiceID,
+		ServiceName:     req.ServiceName,
+		Price:           req.Price,
+		Currency:        req.Currency,
+		BillingInterval: req.BillingInterval,
+		Category:        req.Category,
+		Tags:            req.Tags,
+		UserID:          req.UserID,
+		StartDate:       req.StartDate,
+		EndDate:         req.EndDate,
+		TrialEnd:        req.TrialEnd,
+		Promos:          req.Promos,
+	}
+}
+
+func parseBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// writeOverlap responds with the conflicting IDs, unknown if the database rejected the subscription.
+func writeOverlap(w http.ResponseWriter, err error) {
+	response := OverlapResponse{Errors: []string{postgres.ErrOverlap.Error()}, ConflictingIDs: []uuid.UUID{}}
+	var overlapErr *service.OverlapError
+	if errors.As(err, &overlapErr) {
+		response.ConflictingIDs = overlapErr.IDs
+	}
+	if err := utils.WriteJSON(w, http.StatusConflict, response); err != nil {
+		log.Printf("JSON encode error: %v", err)
+	}
+}
+
+// isTooLarge reports decode errors of bodies over the size limit.
+func isTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// isForbidden reports errors of callers whose roles or identity don't allow the operation.
+func isForbidden(err error) bool {
+	return errors.Is(err, service.ErrForbidden) || errors.Is(err, service.ErrNoOrganization) ||
+		errors.Is(err, service.ErrNoIdentity) || errors.Is(err, service.ErrForeignUserID) ||
+		errors.Is(err, service.ErrForeignOrganization)
+}
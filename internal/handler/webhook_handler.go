@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub/postgres"
+	"subscription-service/internal/service"
+	"subscription-service/internal/webhook"
+	"subscription-service/pkg/utils"
+	"subscription-service/pkg/validator"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	paramWebhookID       = "webhookID"
+	paramDeliveryID      = "deliveryID"
+	errInvalidWebhookID  = "invalid webhook ID"
+	errInvalidDeliveryID = "invalid delivery ID"
+	errWebhookNotFound   = "webhook not found"
+	errDeliveryNotFound  = "delivery not found"
+)
+
+type WebhookHandler struct {
+	srv    service.WebhookManager
+	logger *log.Logger
+}
+
+func NewWebhookHandler(srv service.WebhookManager, logger *log.Logger) *WebhookHandler {
+	return &WebhookHandler{srv: srv, logger: logger}
+}
+
+func (h *WebhookHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/webhooks", h.create).Methods("POST")
+	r.HandleFunc("/webhooks", h.getAll).Methods("GET")
+	r.HandleFunc("/webhooks/dead-letters", h.deadLetters).Methods("GET")
+	r.HandleFunc("/webhooks/deliveries/{deliveryID}/redeliver", h.redeliver).Methods("POST")
+	r.HandleFunc("/webhook/{webhookID}", h.get).Methods("GET")
+	r.HandleFunc("/webhook/{webhookID}", h.delete).Methods("DELETE")
+}
+
+// @Summary		Create Webhook
+// @Description	Register a URL receiving the subscription events it subscribes to: subscription.created, subscription.updated,
+// @Description	subscription.deleted and subscription.ending_soon. Requests are signed with the secret, which is generated
+// @Description	if not given and only returned on creation. The URL must resolve to public addresses unless WEBHOOK_ALLOW_PRIVATE is set
+// @Tags		Webhooks
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Accept		json
+// @Produce		json
+// @Param		webhook	body		model.WebhookRequest	true	"Webhook payload"
+// @Success		201		{object}	model.NewWebhook		"Successfully created webhook"
+// @Failure		400		{object}	utils.ErrorResponse		"Validation error or invalid request body"
+// @Failure		401		{object}	utils.Problem			"Missing or invalid credentials"
+// @Failure		403		{object}	utils.ErrorResponse		"Operation not allowed for the caller"
+// @Failure		413		{object}	utils.ErrorResponse		"Request body is too large"
+// @Failure		500		{object}	utils.ErrorResponse		"Internal server error"
+// @Router		/webhooks [post]
+func (h *WebhookHandler) create(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("CREATE webhook request")
+
+	var req model.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Println("CreateWebhook: decode error:", err)
+		if isTooLarge(err) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errBodyTooLarge)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, errDecodeMsg)
+		return
+	}
+
+	if validationErrs := validator.ValidateWebhookRequest(req); validationErrs != nil {
+		h.logger.Println("CreateWebhook: validation error", validationErrs)
+		utils.WriteValidationErrors(w, validationErrs)
+		return
+	}
+
+	created, err := h.srv.Create(r.Context(), req)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, webhook.ErrPrivateTarget) {
+			h.logger.Println("CreateWebhook: private target:", err)
+			utils.WriteValidationErrors(w, []string{err.Error()})
+			return
+		}
+		h.logger.Println("Failed to create webhook:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusCreated, created)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Get All Webhooks
+// @Description	Get the webhooks ordered by creation time, without their secrets
+// @Tags		Webhooks
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Success		200	{array}		model.Webhook		"A list of webhooks"
+// @Failure		401	{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403	{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		500	{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/webhooks [get]
+func (h *WebhookHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET all webhooks request")
+
+	webhooks, err := h.srv.GetAll(r.Context())
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to get webhooks:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, webhooks)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Get Webhook
+// @Description	Get webhook by ID, without its secret
+// @Tags		Webhooks
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		webhookID	path		string				true	"Webhook ID"	format(uuid)
+// @Success		200			{object}	model.Webhook		"Requested webhook"
+// @Failure		400			{object}	utils.ErrorResponse	"Invalid webhook ID"
+// @Failure		401			{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403			{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		404			{object}	utils.ErrorResponse	"Webhook not found"
+// @Failure		500			{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/webhook/{webhookID} [get]
+func (h *WebhookHandler) get(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET webhook request")
+
+	id, err := uuid.Parse(mux.Vars(r)[paramWebhookID])
+	if err != nil {
+		h.logger.Println("Invalid webhook ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidWebhookID)
+		return
+	}
+
+	webhook, err := h.srv.GetByID(r.Context(), id)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("GetWebhook: webhook not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errWebhookNotFound)
+			return
+		}
+		h.logger.Println("Failed to get webhook:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, webhook)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Delete Webhook
+// @Description	Delete webhook by ID with its pending and dead deliveries
+// @Tags		Webhooks
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		webhookID	path	string	true	"Webhook ID"	format(uuid)
+// @Success		204			"Successfully deleted webhook"
+// @Failure		400			{object}	utils.ErrorResponse	"Invalid webhook ID"
+// @Failure		401			{object}	utils.Problem		"Missing or invalid credentials"
+// @Failure		403			{object}	utils.ErrorResponse	"Operation not allowed for the caller"
+// @Failure		404			{object}	utils.ErrorResponse	"Webhook not found"
+// @Failure		500			{object}	utils.ErrorResponse	"Internal server error"
+// @Router		/webhook/{webhookID} [delete]
+func (h *WebhookHandler) delete(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("DELETE webhook request")
+
+	id, err := uuid.Parse(mux.Vars(r)[paramWebhookID])
+	if err != nil {
+		h.logger.Println("Invalid webhook ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidWebhookID)
+		return
+	}
+
+	err = h.srv.Delete(r.Context(), id)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("DeleteWebhook: webhook not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errWebhookNotFound)
+			return
+		}
+		h.logger.Println("Failed to delete webhook:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary		Get Dead Letters
+// @Description	Get the deliveries that failed every attempt, oldest first. They are only sent again when redelivered
+// @Tags		Webhooks
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Success		200	{array}		model.WebhookDelivery	"A list of dead deliveries"
+// @Failure		401	{object}	utils.Problem			"Missing or invalid credentials"
+// @Failure		403	{object}	utils.ErrorResponse		"Operation not allowed for the caller"
+// @Failure		500	{object}	utils.ErrorResponse		"Internal server error"
+// @Router		/webhooks/dead-letters [get]
+func (h *WebhookHandler) deadLetters(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("GET webhook dead letters request")
+
+	deliveries, err := h.srv.DeadLetters(r.Context())
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		h.logger.Println("Failed to get dead letters:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, deliveries)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
+
+// @Summary		Redeliver Webhook Delivery
+// @Description	Queue a dead delivery again with all attempts, it is sent on the next run of the dispatcher.
+// @Description	Pending and delivered deliveries can't be redelivered
+// @Tags		Webhooks
+// @Security	BearerAuth
+// @Security	ApiKeyAuth
+// @Produce		json
+// @Param		deliveryID	path		string					true	"Delivery ID"	format(uuid)
+// @Success		200			{object}	model.WebhookDelivery	"Queued delivery"
+// @Failure		400			{object}	utils.ErrorResponse		"Invalid delivery ID"
+// @Failure		401			{object}	utils.Problem			"Missing or invalid credentials"
+// @Failure		403			{object}	utils.ErrorResponse		"Operation not allowed for the caller"
+// @Failure		404			{object}	utils.ErrorResponse		"Delivery not found"
+// @Failure		409			{object}	utils.ErrorResponse		"Delivery is still pending or already delivered"
+// @Failure		500			{object}	utils.ErrorResponse		"Internal server error"
+// @Router		/webhooks/deliveries/{deliveryID}/redeliver [post]
+func (h *WebhookHandler) redeliver(w http.ResponseWriter, r *http.Request) {
+	h.logger.Println("REDELIVER webhook delivery request")
+
+	id, err := uuid.Parse(mux.Vars(r)[paramDeliveryID])
+	if err != nil {
+		h.logger.Println("Invalid delivery ID:", err)
+		utils.WriteError(w, http.StatusBadRequest, errInvalidDeliveryID)
+		return
+	}
+
+	delivery, err := h.srv.Redeliver(r.Context(), id)
+	if err != nil {
+		if isForbidden(err) {
+			h.logger.Println("Access denied:", err)
+			utils.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, postgres.ErrNotFound) {
+			h.logger.Println("Redeliver: delivery not found:", err)
+			utils.WriteError(w, http.StatusNotFound, errDeliveryNotFound)
+			return
+		}
+		if errors.Is(err, service.ErrDeliveryPending) || errors.Is(err, service.ErrDeliveryDelivered) {
+			h.logger.Println("Redeliver:", err)
+			utils.WriteError(w, http.StatusConflict, err.Error())
+			return
+		}
+		h.logger.Println("Failed to redeliver:", err)
+		utils.WriteError(w, http.StatusInternalServerError, errInternalMsg)
+		return
+	}
+
+	err = utils.WriteJSON(w, http.StatusOK, delivery)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, errEncodeMsg)
+	}
+}
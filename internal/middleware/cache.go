@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CacheControl lets clients keep successful responses to GET requests for maxAge, or revalidate
+// them if maxAge is 0. Responses depend on the caller, so shared caches mustn't store them.
+// Other responses aren't stored at all.
+func CacheControl(maxAge time.Duration) func(http.Handler) http.Handler {
+	cacheable := "private, no-cache"
+	if maxAge > 0 {
+		cacheable = "private, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := "no-store"
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				value = cacheable
+			}
+			next.ServeHTTP(&cacheControlWriter{ResponseWriter: w, value: value}, r)
+		})
+	}
+}
+
+// cacheControlWriter sets Cache-Control when the status is known, unless the handler set it.
+type cacheControlWriter struct {
+	http.ResponseWriter
+	value       string
+	wroteHeader bool
+}
+
+func (w *cacheControlWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if w.Header().Get("Cache-Control") == "" {
+			if status == http.StatusOK {
+				w.Header().Set("Cache-Control", w.value)
+			} else {
+				w.Header().Set("Cache-Control", "no-store")
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheControlWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheControlWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"subscription-service/internal/reqctx"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderRequestID = "X-Request-ID"
+	HeaderActor     = "X-Actor"
+	// HeaderReadPrimary set to true reads from the primary database instead of a replica.
+	HeaderReadPrimary = "X-Read-Primary"
+)
+
+// RequestContext stores the request ID, the caller name and address and the read preference in the
+// request context. The request ID is taken from X-Request-ID or generated, and echoed in the response.
+func RequestContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if id == "" {
+			id = uuid.NewString()
+		}
+		w.Header().Set(HeaderRequestID, id)
+
+		ctx := reqctx.WithRequestID(r.Context(), id)
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ctx = reqctx.WithClient(ctx, host)
+		} else if r.RemoteAddr != "" {
+			ctx = reqctx.WithClient(ctx, r.RemoteAddr)
+		}
+		if actor := r.Header.Get(HeaderActor); actor != "" {
+			ctx = reqctx.WithActor(ctx, actor)
+		}
+		if primary, _ := strconv.ParseBool(r.Header.Get(HeaderReadPrimary)); primary {
+			ctx = reqctx.WithPrimaryRead(ctx)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"subscription-service/internal/auth"
+	"subscription-service/internal/reqctx"
+	"subscription-service/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+const HeaderOrganization = "X-Organization-ID"
+
+// Tenant stores the organization of the request in the request context. Authenticated requests
+// belong to the organization of their org_id claim and are rejected with 403 without one, so that
+// callers can't pick their tenant. The X-Organization-ID header names the organization only when
+// authentication is disabled. A header contradicting the claim is rejected with 403 and,
+// if required is set, requests without an organization with 400.
+func Tenant(required bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var orgID uuid.UUID
+			if header := r.Header.Get(HeaderOrganization); header != "" {
+				id, err := uuid.Parse(header)
+				if err != nil || id == uuid.Nil {
+					utils.WriteProblem(w, http.StatusBadRequest, "invalid "+HeaderOrganization+" header")
+					return
+				}
+				orgID = id
+			}
+
+			if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+				if claims.OrganizationID == "" {
+					utils.WriteProblem(w, http.StatusForbidden, "credentials have no organization")
+					return
+				}
+				id, err := uuid.Parse(claims.OrganizationID)
+				if err != nil || id == uuid.Nil {
+					utils.WriteProblem(w, http.StatusForbidden, "invalid org_id claim")
+					return
+				}
+				if orgID != uuid.Nil && orgID != id {
+					utils.WriteProblem(w, http.StatusForbidden, "organization doesn't match the org_id claim")
+					return
+				}
+				orgID = id
+			}
+
+			if orgID == uuid.Nil {
+				if required {
+					utils.WriteProblem(w, http.StatusBadRequest, "organization is required")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(reqctx.WithOrganization(r.Context(), orgID)))
+		})
+	}
+}
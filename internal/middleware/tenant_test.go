@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"subscription-service/internal/auth"
+	"subscription-service/internal/reqctx"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTenant(t *testing.T) {
+	org := uuid.New()
+	other := uuid.New()
+
+	tests := []struct {
+		name    string
+		claims  *auth.Claims
+		header  string
+		status  int
+		wantOrg uuid.UUID
+	}{
+		{name: "header without auth", header: org.String(), status: http.StatusOK, wantOrg: org},
+		{name: "no organization without auth", status: http.StatusBadRequest},
+		{name: "claim", claims: &auth.Claims{OrganizationID: org.String()}, status: http.StatusOK, wantOrg: org},
+		{name: "matching header", claims: &auth.Claims{OrganizationID: org.String()}, header: org.String(), status: http.StatusOK, wantOrg: org},
+		{name: "contradicting header", claims: &auth.Claims{OrganizationID: org.String()}, header: other.String(), status: http.StatusForbidden},
+		{name: "header without claim", claims: &auth.Claims{}, header: other.String(), status: http.StatusForbidden},
+		{name: "no claim", claims: &auth.Claims{}, status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotOrg uuid.UUID
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotOrg, _ = reqctx.Organization(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderOrganization, tt.header)
+			}
+			if tt.claims != nil {
+				req = req.WithContext(auth.WithClaims(req.Context(), tt.claims))
+			}
+			rec := httptest.NewRecorder()
+			Tenant(true)(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if gotOrg != tt.wantOrg {
+				t.Errorf("organization = %s, want %s", gotOrg, tt.wantOrg)
+			}
+		})
+	}
+}
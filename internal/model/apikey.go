@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey authenticates service-to-service calls. Only a hash of the secret is stored,
+// Prefix is its first characters to tell keys apart. Scopes are the roles granted by the key.
+type APIKey struct {
+	ID             uuid.UUID  `json:"id"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix"`
+	Scopes         []string   `json:"scopes"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type APIKeyRequest struct {
+	Name           string     `json:"name"`
+	Scopes         []string   `json:"scopes"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// NewAPIKey is returned once on creation, the secret Key can't be retrieved later.
+type NewAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
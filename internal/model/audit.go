@@ -0,0 +1,41 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OperationCreate  = "create"
+	OperationUpdate  = "update"
+	OperationDelete  = "delete"
+	OperationRestore = "restore"
+	OperationPause   = "pause"
+	OperationResume  = "resume"
+	OperationCancel  = "cancel"
+)
+
+// AuditEntry records a single change of a subscription.
+// Diff maps every changed field to its old and new values.
+type AuditEntry struct {
+	ID             uuid.UUID       `json:"id"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	Actor          string          `json:"actor"`
+	Operation      string          `json:"operation"`
+	RequestID      string          `json:"request_id,omitempty"`
+	Before         json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After          json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	Diff           json.RawMessage `json:"diff" swaggertype:"object"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type AuditFilter struct {
+	SubscriptionID uuid.UUID
+	Actor          string
+	From           *time.Time
+	To             *time.Time
+	Limit          int
+	Offset         int
+}
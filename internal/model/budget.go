@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBudgetThresholds are the percents of a budget alerted on if a budget has none.
+var DefaultBudgetThresholds = []int{80, 100}
+
+// Budget limits the monthly spend of the subscriptions of a user, of a category, of both,
+// or of all subscriptions if neither is set. Thresholds are percents of Amount that raise an alert.
+type Budget struct {
+	ID             uuid.UUID  `json:"id"`
+	Name           string     `json:"name"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	Category       *string    `json:"category,omitempty"`
+	Amount         int        `json:"amount"`
+	Currency       string     `json:"currency"`
+	Thresholds     []int      `json:"thresholds"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type BudgetRequest struct {
+	Name       string     `json:"name"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	Category   *string    `json:"category,omitempty"`
+	Amount     int        `json:"amount"`
+	Currency   string     `json:"currency,omitempty"`
+	Thresholds []int      `json:"thresholds,omitempty"`
+}
+
+// BudgetStatus compares the spend of a month with the budget, Crossed lists the thresholds reached.
+type BudgetStatus struct {
+	BudgetID  uuid.UUID `json:"budget_id"`
+	Month     string    `json:"month"`
+	Amount    int       `json:"amount"`
+	Spent     int       `json:"spent"`
+	Remaining int       `json:"remaining"`
+	Percent   int       `json:"percent"`
+	Currency  string    `json:"currency"`
+	Crossed   []int     `json:"crossed"`
+}
+
+// BudgetAlert is raised once per budget, month and threshold when the spend reaches the threshold.
+type BudgetAlert struct {
+	ID             uuid.UUID `json:"id"`
+	BudgetID       uuid.UUID `json:"budget_id"`
+	BudgetName     string    `json:"budget_name"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Month          string    `json:"month"`
+	Threshold      int       `json:"threshold"`
+	Amount         int       `json:"amount"`
+	Spent          int       `json:"spent"`
+	Currency       string    `json:"currency"`
+	CreatedAt      time.Time `json:"created_at"`
+}
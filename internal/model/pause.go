@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Pause stops charging a subscription from the StartDate month through the EndDate month,
+// or until it is resumed without EndDate.
+type Pause struct {
+	ID             uuid.UUID `json:"id"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	StartDate      string    `json:"start_date"`
+	EndDate        *string   `json:"end_date,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type PauseRequest struct {
+	EffectiveFrom string  `json:"effective_from"`
+	Until         *string `json:"until,omitempty"`
+}
+
+// LifecycleRequest resumes or cancels a subscription from the EffectiveFrom month.
+type LifecycleRequest struct {
+	EffectiveFrom string `json:"effective_from"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceChange sets a new subscription price starting from the EffectiveFrom month.
+type PriceChange struct {
+	ID             uuid.UUID `json:"id"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	Price          int       `json:"price"`
+	EffectiveFrom  string    `json:"effective_from"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type PriceChangeRequest struct {
+	Price         int    `json:"price"`
+	EffectiveFrom string `json:"effective_from"`
+}
@@ -0,0 +1,8 @@
+package model
+
+// Promo charges Price instead of the regular price from the StartDate through the EndDate month.
+type Promo struct {
+	Price     int    `json:"price"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
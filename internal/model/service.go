@@ -0,0 +1,22 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Service is a catalog entry subscriptions refer to. Aliases are matched
+// case-insensitively when a subscription is created by service name.
+type Service struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Aliases      []string  `json:"aliases"`
+	Category     *string   `json:"category,omitempty"`
+	DefaultPrice *int      `json:"default_price,omitempty"`
+}
+
+type ServiceRequest struct {
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Category     *string  `json:"category,omitempty"`
+	DefaultPrice *int     `json:"default_price,omitempty"`
+}
@@ -1,22 +1,127 @@
 package model
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
+// DefaultCurrency is used for subscriptions created without an explicit currency.
+const DefaultCurrency = "RUB"
+
+// Groupings of the total sum.
+const (
+	GroupByCategory = "category"
+	GroupByTag      = "tag"
+)
+
+// Subscription statuses.
+const (
+	StatusUpcoming = "upcoming"
+	StatusTrial    = "trial"
+	StatusActive   = "active"
+	StatusPaused   = "paused"
+	StatusEnded    = "ended"
+)
+
 type Subscription struct {
 	ID          uuid.UUID `json:"id"`
+	ServiceID   uuid.UUID `json:"service_id"`
 	ServiceName string    `json:"service_name"`
 	Price       int       `json:"price"`
-	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"`
-	EndDate     *string   `json:"end_date,omitempty"`
+	Currency    string    `json:"currency"`
+	// BillingInterval is the number of months between charges, starting with StartDate.
+	BillingInterval int       `json:"billing_interval"`
+	Category        *string   `json:"category,omitempty"`
+	Tags            []string  `json:"tags"`
+	UserID          uuid.UUID `json:"user_id"`
+	OrganizationID  uuid.UUID `json:"organization_id"`
+	StartDate       string    `json:"start_date"`
+	EndDate         *string   `json:"end_date,omitempty"`
+	// TrialEnd is the last month of a free trial starting with the subscription.
+	TrialEnd  *string    `json:"trial_end,omitempty"`
+	Promos    []Promo    `json:"promos,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	Pauses    []Pause    `json:"pauses,omitempty"`
+	// Status is derived from the dates and pauses for the current month.
+	Status string `json:"status,omitempty" enums:"upcoming,trial,active,paused,ended"`
+	// OverlapsWith lists the overlapping subscriptions of the same user and service
+	// when a write is allowed with a warning.
+	OverlapsWith []uuid.UUID `json:"overlaps_with,omitempty"`
 }
 
+// SubRequest refers to a catalog service by ServiceID or by name or alias in ServiceName.
+// Price may be omitted if the service has a default price.
 type SubRequest struct {
-	ServiceName string    `json:"service_name"`
+	ServiceID   uuid.UUID `json:"service_id,omitempty"`
+	ServiceName string    `json:"service_name,omitempty"`
 	Price       int       `json:"price"`
-	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"`
-	EndDate     *string   `json:"end_date,omitempty"`
+	Currency    string    `json:"currency,omitempty"`
+	// BillingInterval defaults to 1, charging every month.
+	BillingInterval int       `json:"billing_interval,omitempty"`
+	Category        *string   `json:"category,omitempty"`
+	Tags            []string  `json:"tags,omitempty"`
+	UserID          uuid.UUID `json:"user_id"`
+	StartDate       string    `json:"start_date"`
+	EndDate         *string   `json:"end_date,omitempty"`
+	TrialEnd        *string   `json:"trial_end,omitempty"`
+	Promos          []Promo   `json:"promos,omitempty"`
+}
+
+// ListFilter selects subscriptions having the category and all of the tags.
+type ListFilter struct {
+	Category       string
+	Tags           []string
+	IncludeDeleted bool
+	// TrialEndsIn lists subscriptions whose trial ends in the month.
+	TrialEndsIn string
+	// EndsIn lists subscriptions whose last month is the month.
+	EndsIn string
+}
+
+type TotalFilter struct {
+	StartDate      string
+	EndDate        string
+	UserID         uuid.UUID
+	ServiceID      uuid.UUID
+	ServiceName    string
+	Category       string
+	Tags           []string
+	IncludeDeleted bool
+	GroupBy        string
+}
+
+// MonthlySpend is the sum of prices charged in one currency during one month
+// for one group of TotalFilter.GroupBy.
+type MonthlySpend struct {
+	Month    string
+	Currency string
+	Group    string
+	Amount   int
+}
+
+// TotalSum holds the total and, if grouped, the total of every group.
+// A subscription with several tags counts in each of their groups,
+// subscriptions without a category or tags are in the group with empty key.
+type TotalSum struct {
+	TotalSum int        `json:"total_sum"`
+	Currency string     `json:"currency"`
+	Groups   []GroupSum `json:"groups,omitempty"`
+}
+
+// Forecast is the projected spend of the coming months, starting with the current one.
+type Forecast struct {
+	TotalSum int        `json:"total_sum"`
+	Currency string     `json:"currency"`
+	Months   []MonthSum `json:"months"`
+}
+
+type MonthSum struct {
+	Month    string `json:"month"`
+	TotalSum int    `json:"total_sum"`
+}
+
+type GroupSum struct {
+	Key      string `json:"key"`
+	TotalSum int    `json:"total_sum"`
 }
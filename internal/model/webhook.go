@@ -0,0 +1,76 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription events delivered to webhooks.
+const (
+	EventSubscriptionCreated    = "subscription.created"
+	EventSubscriptionUpdated    = "subscription.updated"
+	EventSubscriptionDeleted    = "subscription.deleted"
+	EventSubscriptionEndingSoon = "subscription.ending_soon"
+)
+
+// WebhookEvents lists every event a webhook can subscribe to.
+var WebhookEvents = []string{
+	EventSubscriptionCreated, EventSubscriptionUpdated, EventSubscriptionDeleted, EventSubscriptionEndingSoon,
+}
+
+// Delivery statuses. Dead deliveries ran out of attempts and are only sent again when redelivered.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// Webhook receives the events it subscribed to, signed with its secret.
+type Webhook struct {
+	ID             uuid.UUID `json:"id"`
+	URL            string    `json:"url"`
+	Events         []string  `json:"events"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Secret         string    `json:"-"`
+}
+
+// WebhookRequest creates a webhook, a secret is generated if none is given.
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events"`
+}
+
+// NewWebhook is returned once on creation, the secret can't be retrieved later.
+type NewWebhook struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookEvent is the payload posted to webhooks.
+type WebhookEvent struct {
+	ID             uuid.UUID     `json:"id"`
+	Type           string        `json:"type"`
+	OccurredAt     time.Time     `json:"occurred_at"`
+	OrganizationID uuid.UUID     `json:"organization_id"`
+	Data           *Subscription `json:"data"`
+}
+
+// WebhookDelivery is an event queued for one webhook.
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status" enums:"pending,delivered,dead"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      *string         `json:"last_error,omitempty"`
+	OrganizationID uuid.UUID       `json:"organization_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"subscription-service/internal/model"
+)
+
+// Notifier delivers budget alerts. A failed delivery is retried on the next evaluation.
+type Notifier interface {
+	Notify(ctx context.Context, alert model.BudgetAlert) error
+}
+
+// LogNotifier writes alerts to the log.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, alert model.BudgetAlert) error {
+	n.logger.Printf("Budget %q (%s) reached %d%% in %s: spent %d of %d %s",
+		alert.BudgetName, alert.BudgetID, alert.Threshold, alert.Month, alert.Spent, alert.Amount, alert.Currency)
+	return nil
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"subscription-service/internal/auth"
+	"subscription-service/pkg/utils"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// KeyFunc identifies the client a request is counted for.
+type KeyFunc func(r *http.Request) string
+
+// Limiter limits requests of every client with a token bucket per route. Routes are
+// named "METHOD /path/template", routes without an own limit share the default bucket.
+type Limiter struct {
+	store  Store
+	limit  Limit
+	routes map[string]Limit
+	key    KeyFunc
+	logger *log.Logger
+}
+
+func NewLimiter(store Store, limit Limit, routes map[string]Limit, key KeyFunc, logger *log.Logger) *Limiter {
+	return &Limiter{store: store, limit: limit, routes: routes, key: key, logger: logger}
+}
+
+// Middleware counts the request for the client of the key function. Requests over the limit
+// get 429, a failing store lets requests through.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, bucket := l.limit, "default"
+		if route := routeName(r); route != "" {
+			if routeLimit, ok := l.routes[route]; ok {
+				limit, bucket = routeLimit, route
+			}
+		}
+
+		res, err := l.store.Take(r.Context(), l.key(r)+"|"+bucket, limit, time.Now())
+		if err != nil {
+			l.logger.Println("Rate limit store error:", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		if !res.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			utils.WriteProblem(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func routeName(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return r.Method + " " + template
+}
+
+// ClientIP identifies the client by its IP. Limiters running before authentication must use it,
+// keying by unverified credentials would give every made up key or token a bucket of its own.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// Caller identifies authenticated clients by their API key or token subject, others by their IP.
+// It must run after authentication.
+func Caller(r *http.Request) string {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		return ClientIP(r)
+	}
+	if id, ok := claims.APIKeyID(); ok {
+		return "key:" + id
+	}
+	return "user:" + claims.Subject
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"subscription-service/internal/auth"
+	"subscription-service/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+)
+
+func newTestRouter(limiter *Limiter) *mux.Router {
+	r := mux.NewRouter()
+	r.Use(limiter.Middleware)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	r.HandleFunc("/subscriptions", ok).Methods("GET")
+	r.HandleFunc("/subscriptions/total", ok).Methods("GET")
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	routes := map[string]Limit{"GET /subscriptions/total": {Rate: 0.001, Burst: 1}}
+	limiter := NewLimiter(NewMemoryStore(), Limit{Rate: 0.001, Burst: 2}, routes, ClientIP, log.New(io.Discard, "", 0))
+	router := newTestRouter(limiter)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get("/subscriptions")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	for header, want := range map[string]string{"RateLimit-Limit": "2", "RateLimit-Remaining": "1", "RateLimit-Reset": "1000"} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	// The route has a bucket of its own.
+	if rec = get("/subscriptions/total"); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "1" {
+		t.Fatalf("route: status = %d, limit = %q", rec.Code, rec.Header().Get("RateLimit-Limit"))
+	}
+	rec = get("/subscriptions/total")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("over the route limit: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1000" {
+		t.Errorf("Retry-After = %q, want %q", got, "1000")
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	var problem utils.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("decode problem: %v", err)
+	}
+	if problem.Status != http.StatusTooManyRequests || problem.Title != "Too Many Requests" || problem.Detail != "rate limit exceeded" {
+		t.Errorf("problem = %+v", problem)
+	}
+
+	if rec = get("/subscriptions"); rec.Code != http.StatusOK {
+		t.Errorf("default bucket: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit, time.Time) (Result, error) {
+	return Result{}, errors.New("store down")
+}
+
+func TestMiddlewareStoreError(t *testing.T) {
+	router := newTestRouter(NewLimiter(failingStore{}, Limit{Rate: 1, Burst: 1}, nil, ClientIP, log.New(io.Discard, "", 0)))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want the request let through", rec.Code)
+	}
+}
+
+func TestCaller(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		want   string
+	}{
+		{"anonymous", nil, "ip:192.0.2.1"},
+		{"token", &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "alice"}}, "user:alice"},
+		{"API key", &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: auth.APIKeySubject + "42"}}, "key:42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			if tt.claims != nil {
+				req = req.WithContext(auth.WithClaims(req.Context(), tt.claims))
+			}
+			if got := Caller(req); got != tt.want {
+				t.Errorf("Caller = %q, want %q", got, tt.want)
+			}
+			if got := ClientIP(req); got != "ip:192.0.2.1" {
+				t.Errorf("ClientIP = %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreBurstAndRefill(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Rate: 2, Burst: 3}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	take := func(key string, at time.Time) Result {
+		t.Helper()
+		res, err := store.Take(context.Background(), key, limit, at)
+		if err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+		return res
+	}
+
+	for i := range limit.Burst {
+		res := take("a", now)
+		if !res.Allowed || res.Remaining != limit.Burst-1-i {
+			t.Fatalf("request %d: allowed = %t, remaining = %d, want allowed with %d remaining",
+				i+1, res.Allowed, res.Remaining, limit.Burst-1-i)
+		}
+	}
+	res := take("a", now)
+	if res.Allowed || res.RetryAfter != 500*time.Millisecond || res.Reset != 1500*time.Millisecond {
+		t.Fatalf("over the burst: allowed = %t, retry after = %s, reset = %s, want denied, 500ms and 1.5s",
+			res.Allowed, res.RetryAfter, res.Reset)
+	}
+	if res = take("b", now); !res.Allowed {
+		t.Error("another key shares the bucket")
+	}
+
+	// Half a second refills one token, the bucket never holds more than the burst.
+	if res = take("a", now.Add(500*time.Millisecond)); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("after refill: allowed = %t, remaining = %d, want allowed with 0 remaining", res.Allowed, res.Remaining)
+	}
+	if res = take("a", now.Add(time.Hour)); !res.Allowed || res.Remaining != limit.Burst-1 {
+		t.Errorf("after an hour: remaining = %d, want %d", res.Remaining, limit.Burst-1)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := store.Take(context.Background(), "idle", limit, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Take(context.Background(), "active", limit, now.Add(2*sweepInterval)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("refilled bucket wasn't swept")
+	}
+}
@@ -0,0 +1,19 @@
+package sub
+
+import (
+	"context"
+	"subscription-service/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyRepository stores API keys by the hash of their secret. A repository scoped to an organization
+// only lists and revokes the keys of the organization, keys are looked up by hash in all of them.
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey, hash string) error
+	GetAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error
+	TouchAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error
+}
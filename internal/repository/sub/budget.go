@@ -0,0 +1,19 @@
+package sub
+
+import (
+	"context"
+	"subscription-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// BudgetRepository stores budgets and the alerts raised for them.
+// Budgets of a user-scoped repository are the ones of that user.
+type BudgetRepository interface {
+	CreateBudget(ctx context.Context, budget *model.Budget) error
+	GetBudgetByID(ctx context.Context, id uuid.UUID) (*model.Budget, error)
+	GetBudgets(ctx context.Context) ([]model.Budget, error)
+	DeleteBudget(ctx context.Context, id uuid.UUID) error
+	// AddBudgetAlert records the alert, ErrConflict if the threshold was already alerted in the month.
+	AddBudgetAlert(ctx context.Context, alert *model.BudgetAlert) error
+}
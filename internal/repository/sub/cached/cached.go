@@ -0,0 +1,353 @@
+package cached
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"subscription-service/internal/cache"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entry is a cached result along with the subscriptions it depends on.
+type Entry struct {
+	deps  deps
+	value any
+}
+
+// deps limits the subscriptions a result depends on, nil IDs don't limit them.
+type deps struct {
+	subID     uuid.UUID
+	orgID     uuid.UUID
+	userID    uuid.UUID
+	serviceID uuid.UUID
+}
+
+// change describes a changed subscription, nil IDs are unknown and match every result.
+type change struct {
+	subID     uuid.UUID
+	orgID     uuid.UUID
+	userID    uuid.UUID
+	serviceID uuid.UUID
+}
+
+func (d deps) affectedBy(c change) bool {
+	match := func(dep, changed uuid.UUID) bool {
+		return dep == uuid.Nil || changed == uuid.Nil || dep == changed
+	}
+	return match(d.subID, c.subID) && match(d.orgID, c.orgID) && match(d.userID, c.userID) && match(d.serviceID, c.serviceID)
+}
+
+// SubCachedRepository caches subscriptions, lists and monthly spend of the wrapped repository.
+// Writes invalidate the results depending on the changed subscriptions, inside a transaction
+// once it is committed. Transactions read from the wrapped repository.
+type SubCachedRepository struct {
+	sub.SubscriptionRepository
+	cache *cache.LRU[Entry]
+	lag   *lagWindow
+	// orgID and userID are the scope of the repository.
+	orgID  uuid.UUID
+	userID uuid.UUID
+	tx     *txState
+}
+
+// lagWindow bypasses the cache until a read replica caught up with the last invalidation,
+// as results read from it before could be cached as current.
+type lagWindow struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+// invalidated starts the window after an invalidation.
+func (l *lagWindow) invalidated() {
+	if l.window <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.until = time.Now().Add(l.window)
+}
+
+// lagging tells whether the replica may still miss the last invalidated change.
+func (l *lagWindow) lagging() bool {
+	if l.window <= 0 {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Now().Before(l.until)
+}
+
+// txState collects the changes of a transaction and the subscriptions it read,
+// which tell the owners of subscriptions changed by ID.
+type txState struct {
+	seen    map[uuid.UUID]model.Subscription
+	changes []change
+}
+
+// NewSubCachedRepository caches the results of the repository. With a read replica, replicaLag
+// is the longest the replica may lag behind, results aren't cached for that long after a write.
+func NewSubCachedRepository(repo sub.SubscriptionRepository, cache *cache.LRU[Entry], replicaLag time.Duration) *SubCachedRepository {
+	return &SubCachedRepository{SubscriptionRepository: repo, cache: cache, lag: &lagWindow{window: replicaLag}}
+}
+
+func (r *SubCachedRepository) wrap(repo sub.SubscriptionRepository) *SubCachedRepository {
+	wrapped := *r
+	wrapped.SubscriptionRepository = repo
+	return &wrapped
+}
+
+func (r *SubCachedRepository) ForUser(userID uuid.UUID) sub.SubscriptionRepository {
+	scoped := r.wrap(r.SubscriptionRepository.ForUser(userID))
+	scoped.userID = userID
+	return scoped
+}
+
+func (r *SubCachedRepository) ForOrganization(orgID uuid.UUID) sub.SubscriptionRepository {
+	scoped := r.wrap(r.SubscriptionRepository.ForOrganization(orgID))
+	scoped.orgID = orgID
+	return scoped
+}
+
+func (r *SubCachedRepository) WithTx(ctx context.Context, fn func(repo sub.SubscriptionRepository) error) error {
+	if r.tx != nil {
+		return r.SubscriptionRepository.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+			return fn(r.wrap(repo))
+		})
+	}
+
+	tx := &txState{seen: make(map[uuid.UUID]model.Subscription)}
+	err := r.SubscriptionRepository.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		txRepo := r.wrap(repo)
+		txRepo.tx = tx
+		return fn(txRepo)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range tx.changes {
+		r.invalidate(c)
+	}
+	return nil
+}
+
+func (r *SubCachedRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	if r.tx != nil {
+		subscription, err := r.SubscriptionRepository.GetByID(ctx, id)
+		if err == nil {
+			r.tx.seen[id] = *subscription
+		}
+		return subscription, err
+	}
+
+	value, err := r.load("get", id, deps{subID: id}, func() (any, error) {
+		return r.SubscriptionRepository.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	subscription := *value.(*model.Subscription)
+	subscription.Tags = slices.Clone(subscription.Tags)
+	subscription.Pauses = slices.Clone(subscription.Pauses)
+	subscription.Promos = slices.Clone(subscription.Promos)
+	return &subscription, nil
+}
+
+func (r *SubCachedRepository) GetAll(ctx context.Context, filter model.ListFilter) ([]model.Subscription, error) {
+	if r.tx != nil {
+		return r.SubscriptionRepository.GetAll(ctx, filter)
+	}
+
+	value, err := r.load("list", filter, deps{}, func() (any, error) {
+		return r.SubscriptionRepository.GetAll(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+	subs := slices.Clone(value.([]model.Subscription))
+	for i := range subs {
+		subs[i].Tags = slices.Clone(subs[i].Tags)
+		subs[i].Pauses = slices.Clone(subs[i].Pauses)
+		subs[i].Promos = slices.Clone(subs[i].Promos)
+	}
+	return subs, nil
+}
+
+func (r *SubCachedRepository) GetMonthlySpend(ctx context.Context, filter model.TotalFilter) ([]model.MonthlySpend, error) {
+	if r.tx != nil {
+		return r.SubscriptionRepository.GetMonthlySpend(ctx, filter)
+	}
+
+	value, err := r.load("spend", filter, deps{userID: filter.UserID, serviceID: filter.ServiceID}, func() (any, error) {
+		return r.SubscriptionRepository.GetMonthlySpend(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(value.([]model.MonthlySpend)), nil
+}
+
+// load returns the cached result of the query with the arguments in the repository scope,
+// or runs the query and caches the result. Errors aren't cached. While the replica lags behind
+// a write, the query runs uncached: the write only removed the results cached before it.
+func (r *SubCachedRepository) load(query string, args any, d deps, fn func() (any, error)) (any, error) {
+	if r.lag.lagging() {
+		return fn()
+	}
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return fn()
+	}
+	key := query + "|" + r.orgID.String() + "|" + r.userID.String() + "|" + string(encoded)
+	if e, ok := r.cache.Get(key); ok {
+		return e.value, nil
+	}
+
+	version := r.cache.Version()
+	value, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	// The scope limits the result like the filter does.
+	d.orgID = r.orgID
+	if r.userID != uuid.Nil {
+		if d.userID != uuid.Nil && d.userID != r.userID {
+			return value, nil
+		}
+		d.userID = r.userID
+	}
+	r.cache.SetIfVersion(key, Entry{deps: d, value: value}, version)
+	return value, nil
+}
+
+func (r *SubCachedRepository) Create(ctx context.Context, subscription *model.Subscription) error {
+	if err := r.SubscriptionRepository.Create(ctx, subscription); err != nil {
+		return err
+	}
+	r.changed(changeOf(subscription))
+	return nil
+}
+
+func (r *SubCachedRepository) Update(ctx context.Context, id uuid.UUID, subscription *model.Subscription) (*model.Subscription, error) {
+	before := r.changeByID(id)
+	updated, err := r.SubscriptionRepository.Update(ctx, id, subscription)
+	if err != nil {
+		return nil, err
+	}
+	r.changed(before)
+	r.changed(changeOf(updated))
+	return updated, nil
+}
+
+func (r *SubCachedRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	c := r.changeByID(id)
+	if err := r.SubscriptionRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.changed(c)
+	return nil
+}
+
+// Restore reads the restored subscription to know its owner, as deleted ones can't be read.
+func (r *SubCachedRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	if err := r.SubscriptionRepository.Restore(ctx, id); err != nil {
+		return err
+	}
+	c := change{subID: id, orgID: r.orgID, userID: r.userID}
+	if restored, err := r.SubscriptionRepository.GetByID(ctx, id); err == nil {
+		c = changeOf(restored)
+	}
+	r.changed(c)
+	return nil
+}
+
+func (r *SubCachedRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	purged, err := r.SubscriptionRepository.Purge(ctx, deletedBefore)
+	if err != nil || purged == 0 {
+		return purged, err
+	}
+	r.changed(change{orgID: r.orgID, userID: r.userID})
+	return purged, nil
+}
+
+func (r *SubCachedRepository) AddPriceChange(ctx context.Context, priceChange *model.PriceChange) error {
+	c := r.changeByID(priceChange.SubscriptionID)
+	if err := r.SubscriptionRepository.AddPriceChange(ctx, priceChange); err != nil {
+		return err
+	}
+	r.changed(c)
+	return nil
+}
+
+func (r *SubCachedRepository) AddPause(ctx context.Context, pause *model.Pause) error {
+	c := r.changeByID(pause.SubscriptionID)
+	if err := r.SubscriptionRepository.AddPause(ctx, pause); err != nil {
+		return err
+	}
+	r.changed(c)
+	return nil
+}
+
+func (r *SubCachedRepository) UpdatePause(ctx context.Context, pause *model.Pause) error {
+	c := r.changeByID(pause.SubscriptionID)
+	if err := r.SubscriptionRepository.UpdatePause(ctx, pause); err != nil {
+		return err
+	}
+	r.changed(c)
+	return nil
+}
+
+func (r *SubCachedRepository) DeletePause(ctx context.Context, subID, id uuid.UUID) error {
+	c := r.changeByID(subID)
+	if err := r.SubscriptionRepository.DeletePause(ctx, subID, id); err != nil {
+		return err
+	}
+	r.changed(c)
+	return nil
+}
+
+// UpdateService renames the subscriptions of the service.
+func (r *SubCachedRepository) UpdateService(ctx context.Context, id uuid.UUID, service *model.Service) error {
+	if err := r.SubscriptionRepository.UpdateService(ctx, id, service); err != nil {
+		return err
+	}
+	r.changed(change{serviceID: id})
+	return nil
+}
+
+// changeByID describes a change of the subscription from what the transaction read of it,
+// or by the repository scope if nothing was read.
+func (r *SubCachedRepository) changeByID(id uuid.UUID) change {
+	if r.tx != nil {
+		if seen, ok := r.tx.seen[id]; ok {
+			return changeOf(&seen)
+		}
+	}
+	return change{subID: id, orgID: r.orgID, userID: r.userID}
+}
+
+func changeOf(s *model.Subscription) change {
+	return change{subID: s.ID, orgID: s.OrganizationID, userID: s.UserID, serviceID: s.ServiceID}
+}
+
+func (r *SubCachedRepository) changed(c change) {
+	if r.tx != nil {
+		r.tx.changes = append(r.tx.changes, c)
+		return
+	}
+	r.invalidate(c)
+}
+
+func (r *SubCachedRepository) invalidate(c change) {
+	r.lag.invalidated()
+	r.cache.RemoveFunc(func(_ string, e Entry) bool {
+		return e.deps.affectedBy(c)
+	})
+}
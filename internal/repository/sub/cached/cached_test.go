@@ -0,0 +1,255 @@
+package cached
+
+import (
+	"context"
+	"errors"
+	"subscription-service/internal/cache"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/internal/repository/sub/memory"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// counter counts the reads reaching the wrapped repository, afterRead runs after every read.
+type counter struct {
+	reads     int
+	afterRead func()
+}
+
+type countingRepo struct {
+	sub.SubscriptionRepository
+	counter *counter
+}
+
+func (r *countingRepo) read() {
+	r.counter.reads++
+	if r.counter.afterRead != nil {
+		r.counter.afterRead()
+	}
+}
+
+func (r *countingRepo) ForUser(userID uuid.UUID) sub.SubscriptionRepository {
+	return &countingRepo{r.SubscriptionRepository.ForUser(userID), r.counter}
+}
+
+func (r *countingRepo) ForOrganization(orgID uuid.UUID) sub.SubscriptionRepository {
+	return &countingRepo{r.SubscriptionRepository.ForOrganization(orgID), r.counter}
+}
+
+func (r *countingRepo) WithTx(ctx context.Context, fn func(repo sub.SubscriptionRepository) error) error {
+	return r.SubscriptionRepository.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		return fn(&countingRepo{repo, r.counter})
+	})
+}
+
+func (r *countingRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	defer r.read()
+	return r.SubscriptionRepository.GetByID(ctx, id)
+}
+
+func (r *countingRepo) GetAll(ctx context.Context, filter model.ListFilter) ([]model.Subscription, error) {
+	defer r.read()
+	return r.SubscriptionRepository.GetAll(ctx, filter)
+}
+
+func (r *countingRepo) GetMonthlySpend(ctx context.Context, filter model.TotalFilter) ([]model.MonthlySpend, error) {
+	defer r.read()
+	return r.SubscriptionRepository.GetMonthlySpend(ctx, filter)
+}
+
+func newTestRepo(t *testing.T) (*SubCachedRepository, *counter) {
+	t.Helper()
+	return newLaggingTestRepo(t, 0)
+}
+
+func newLaggingTestRepo(t *testing.T, replicaLag time.Duration) (*SubCachedRepository, *counter) {
+	t.Helper()
+	c := &counter{}
+	repo := &countingRepo{memory.NewSubMemoryRepository(), c}
+	return NewSubCachedRepository(repo, cache.New[Entry](100, time.Hour), replicaLag), c
+}
+
+func create(t *testing.T, repo sub.SubscriptionRepository, userID uuid.UUID) *model.Subscription {
+	t.Helper()
+	s := &model.Subscription{ServiceID: uuid.New(), ServiceName: "Netflix", Price: 100, Currency: "RUB",
+		UserID: userID, StartDate: "01-2025", BillingInterval: 1}
+	if err := repo.Create(context.Background(), s); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return s
+}
+
+// expectReads runs fn and checks the number of reads reaching the wrapped repository.
+func expectReads(t *testing.T, c *counter, want int, what string, fn func()) {
+	t.Helper()
+	before := c.reads
+	fn()
+	if got := c.reads - before; got != want {
+		t.Errorf("%s: %d reads of the repository, want %d", what, got, want)
+	}
+}
+
+func TestWritesInvalidate(t *testing.T) {
+	ctx := context.Background()
+	repo, c := newTestRepo(t)
+	s := create(t, repo, uuid.New())
+	filter := model.TotalFilter{StartDate: "01-2025", EndDate: "03-2025"}
+
+	expectReads(t, c, 3, "first reads", func() {
+		repo.GetByID(ctx, s.ID)
+		repo.GetAll(ctx, model.ListFilter{})
+		repo.GetMonthlySpend(ctx, filter)
+	})
+	expectReads(t, c, 0, "cached reads", func() {
+		repo.GetByID(ctx, s.ID)
+		repo.GetAll(ctx, model.ListFilter{})
+		repo.GetMonthlySpend(ctx, filter)
+	})
+
+	update := *s
+	update.Price = 200
+	if _, err := repo.Update(ctx, s.ID, &update); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := repo.GetByID(ctx, s.ID)
+	if err != nil || got.Price != 200 {
+		t.Fatalf("GetByID after update = %+v, %v, want price 200", got, err)
+	}
+	spend, err := repo.GetMonthlySpend(ctx, filter)
+	if err != nil || len(spend) != 3 || spend[0].Amount != 200 {
+		t.Errorf("spend after update = %+v, %v", spend, err)
+	}
+
+	// Cached values are copies.
+	got.Tags = append(got.Tags, "changed")
+	if again, _ := repo.GetByID(ctx, s.ID); len(again.Tags) != 0 {
+		t.Errorf("cached subscription changed through a returned copy: %v", again.Tags)
+	}
+}
+
+func TestScopeKeys(t *testing.T) {
+	ctx := context.Background()
+	repo, c := newTestRepo(t)
+	alice, bob := uuid.New(), uuid.New()
+	create(t, repo, alice)
+	create(t, repo, bob)
+
+	aliceRepo, bobRepo := repo.ForUser(alice), repo.ForUser(bob)
+	for _, scoped := range []sub.SubscriptionRepository{repo, aliceRepo, bobRepo} {
+		expectReads(t, c, 1, "first list of the scope", func() { scoped.GetAll(ctx, model.ListFilter{}) })
+	}
+	if subs, _ := aliceRepo.GetAll(ctx, model.ListFilter{}); len(subs) != 1 || subs[0].UserID != alice {
+		t.Errorf("alice lists %+v", subs)
+	}
+	if subs, _ := repo.GetAll(ctx, model.ListFilter{}); len(subs) != 2 {
+		t.Errorf("unscoped list has %d subscriptions, want 2", len(subs))
+	}
+
+	// A change of alice's subscriptions keeps the lists bob can see, but not the unscoped one.
+	create(t, aliceRepo, alice)
+	expectReads(t, c, 0, "bob's list", func() { bobRepo.GetAll(ctx, model.ListFilter{}) })
+	expectReads(t, c, 1, "alice's list", func() { aliceRepo.GetAll(ctx, model.ListFilter{}) })
+	expectReads(t, c, 1, "unscoped list", func() { repo.GetAll(ctx, model.ListFilter{}) })
+
+	// The spend of a user filter depends on that user only.
+	aliceSpend := model.TotalFilter{StartDate: "01-2025", EndDate: "01-2025", UserID: alice}
+	bobSpend := model.TotalFilter{StartDate: "01-2025", EndDate: "01-2025", UserID: bob}
+	repo.GetMonthlySpend(ctx, aliceSpend)
+	repo.GetMonthlySpend(ctx, bobSpend)
+	create(t, repo, bob)
+	expectReads(t, c, 0, "alice's spend", func() { repo.GetMonthlySpend(ctx, aliceSpend) })
+	expectReads(t, c, 1, "bob's spend", func() { repo.GetMonthlySpend(ctx, bobSpend) })
+}
+
+func TestTransactionInvalidatesOnCommit(t *testing.T) {
+	ctx := context.Background()
+	repo, c := newTestRepo(t)
+	s := create(t, repo, uuid.New())
+	repo.GetByID(ctx, s.ID)
+
+	update := *s
+	update.Price = 200
+	err := repo.WithTx(ctx, func(tx sub.SubscriptionRepository) error {
+		if _, err := tx.GetByID(ctx, s.ID); err != nil {
+			return err
+		}
+		if _, err := tx.Update(ctx, s.ID, &update); err != nil {
+			return err
+		}
+		// Until the commit, others still see the cached subscription.
+		expectReads(t, c, 0, "read during the transaction", func() { repo.GetByID(ctx, s.ID) })
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	got, _ := repo.GetByID(ctx, s.ID)
+	if got.Price != 200 {
+		t.Errorf("price after commit = %d, want 200", got.Price)
+	}
+
+	rollback := errors.New("rollback")
+	err = repo.WithTx(ctx, func(tx sub.SubscriptionRepository) error {
+		update.Price = 300
+		if _, err := tx.Update(ctx, s.ID, &update); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("WithTx: err = %v, want %v", err, rollback)
+	}
+	expectReads(t, c, 0, "read after rollback", func() { repo.GetByID(ctx, s.ID) })
+}
+
+func TestInvalidationDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	repo, c := newTestRepo(t)
+	s := create(t, repo, uuid.New())
+
+	// A write committing after the subscription was loaded must keep the stale value out of the cache.
+	update := *s
+	update.Price = 200
+	c.afterRead = func() {
+		c.afterRead = nil
+		if _, err := repo.Update(ctx, s.ID, &update); err != nil {
+			t.Errorf("Update: %v", err)
+		}
+	}
+	if stale, _ := repo.GetByID(ctx, s.ID); stale.Price != 100 {
+		t.Fatalf("price = %d, want the stale 100", stale.Price)
+	}
+
+	expectReads(t, c, 1, "read after the concurrent write", func() {
+		got, err := repo.GetByID(ctx, s.ID)
+		if err != nil || got.Price != 200 {
+			t.Errorf("GetByID = %+v, %v, want price 200", got, err)
+		}
+	})
+}
+
+func TestReplicaLagBypassesCache(t *testing.T) {
+	ctx := context.Background()
+	const lag = 50 * time.Millisecond
+	repo, c := newLaggingTestRepo(t, lag)
+	s := create(t, repo, uuid.New())
+
+	// Until the replica caught up with the write, reads may return what it had before.
+	expectReads(t, c, 2, "reads during the replica lag", func() {
+		repo.GetByID(ctx, s.ID)
+		repo.GetByID(ctx, s.ID)
+	})
+
+	time.Sleep(lag)
+	expectReads(t, c, 1, "reads after the replica lag", func() {
+		repo.GetByID(ctx, s.ID)
+		repo.GetByID(ctx, s.ID)
+	})
+
+	// A write of another subscription opens the window again.
+	create(t, repo, uuid.New())
+	expectReads(t, c, 1, "read after another write", func() { repo.GetByID(ctx, s.ID) })
+}
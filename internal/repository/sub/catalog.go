@@ -0,0 +1,18 @@
+package sub
+
+import (
+	"context"
+	"subscription-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// CatalogRepository stores the service catalog. Aliases are stored normalized.
+type CatalogRepository interface {
+	CreateService(ctx context.Context, service *model.Service) error
+	GetServiceByID(ctx context.Context, id uuid.UUID) (*model.Service, error)
+	GetServices(ctx context.Context) ([]model.Service, error)
+	UpdateService(ctx context.Context, id uuid.UUID, service *model.Service) error
+	DeleteService(ctx context.Context, id uuid.UUID) error
+	ResolveService(ctx context.Context, alias string) (*model.Service, error)
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func copyAPIKey(key model.APIKey) *model.APIKey {
+	key.Scopes = slices.Clone(key.Scopes)
+	return &key
+}
+
+func (r *SubMemoryRepository) CreateAPIKey(ctx context.Context, key *model.APIKey, hash string) error {
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+	key.CreatedAt = time.Now()
+	return r.write(ctx, func(d *data) error {
+		if _, ok := d.keys[key.ID]; ok {
+			return sub.ErrConflict
+		}
+		for _, row := range d.keys {
+			if row.hash == hash {
+				return sub.ErrConflict
+			}
+		}
+		d.keys[key.ID] = apiKeyRow{key: *copyAPIKey(*key), hash: hash}
+		return nil
+	})
+}
+
+// keyVisible tells whether the key is in the organization scope of the repository.
+func (r *SubMemoryRepository) keyVisible(key model.APIKey) bool {
+	return r.orgID == uuid.Nil || (key.OrganizationID != nil && *key.OrganizationID == r.orgID)
+}
+
+// GetAPIKeys returns the keys ordered by creation time.
+func (r *SubMemoryRepository) GetAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	keys := make([]model.APIKey, 0)
+	err := r.read(func(d *data) error {
+		for _, row := range d.keys {
+			if r.keyVisible(row.key) {
+				keys = append(keys, *copyAPIKey(row.key))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(keys, func(a, b model.APIKey) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return keys, nil
+}
+
+func (r *SubMemoryRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	var key *model.APIKey
+	err := r.read(func(d *data) error {
+		for _, row := range d.keys {
+			if row.hash == hash {
+				key = copyAPIKey(row.key)
+				return nil
+			}
+		}
+		return sub.ErrNotFound
+	})
+	return key, err
+}
+
+func (r *SubMemoryRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.write(ctx, func(d *data) error {
+		row, ok := d.keys[id]
+		if !ok || row.key.RevokedAt != nil || !r.keyVisible(row.key) {
+			return sub.ErrNotFound
+		}
+		row.key.RevokedAt = &at
+		d.keys[id] = row
+		return nil
+	})
+}
+
+// TouchAPIKey records the key use with minute precision like the Postgres repository.
+func (r *SubMemoryRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	at = at.Truncate(time.Minute)
+	return r.write(ctx, func(d *data) error {
+		row, ok := d.keys[id]
+		if ok && (row.key.LastUsedAt == nil || row.key.LastUsedAt.Before(at)) {
+			row.key.LastUsedAt = &at
+			d.keys[id] = row
+		}
+		return nil
+	})
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"subscription-service/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (r *SubMemoryRepository) AddAuditEntry(ctx context.Context, entry *model.AuditEntry) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	entry.CreatedAt = time.Now()
+	return r.write(ctx, func(d *data) error {
+		s := d.subs[entry.SubscriptionID]
+		d.audit = append(d.audit, auditRow{entry: *entry, orgID: s.OrganizationID, userID: s.UserID})
+		return nil
+	})
+}
+
+// GetAuditEntries returns the entries ordered by creation time.
+func (r *SubMemoryRepository) GetAuditEntries(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	entries := make([]model.AuditEntry, 0)
+	err := r.read(func(d *data) error {
+		for _, row := range d.audit {
+			e := row.entry
+			switch {
+			case filter.SubscriptionID != uuid.Nil && e.SubscriptionID != filter.SubscriptionID,
+				filter.Actor != "" && e.Actor != filter.Actor,
+				filter.From != nil && e.CreatedAt.Before(*filter.From),
+				filter.To != nil && e.CreatedAt.After(*filter.To),
+				r.orgID != uuid.Nil && row.orgID != r.orgID,
+				r.userID != uuid.Nil && row.userID != r.userID:
+				continue
+			}
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(entries, func(a, b model.AuditEntry) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+	entries = entries[min(filter.Offset, len(entries)):]
+	return entries[:min(filter.Limit, len(entries))], nil
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type budgetAlertKey struct {
+	budgetID  uuid.UUID
+	month     string
+	threshold int
+}
+
+func copyBudget(budget model.Budget) *model.Budget {
+	budget.Thresholds = slices.Clone(budget.Thresholds)
+	return &budget
+}
+
+// budgetVisible tells whether the budget is in the repository scope.
+func (r *SubMemoryRepository) budgetVisible(budget model.Budget) bool {
+	return (r.orgID == uuid.Nil || budget.OrganizationID == r.orgID) &&
+		(r.userID == uuid.Nil || (budget.UserID != nil && *budget.UserID == r.userID))
+}
+
+func (r *SubMemoryRepository) CreateBudget(ctx context.Context, budget *model.Budget) error {
+	if budget.ID == uuid.Nil {
+		budget.ID = uuid.New()
+	}
+	if r.orgID != uuid.Nil {
+		budget.OrganizationID = r.orgID
+	}
+	budget.CreatedAt = time.Now()
+	return r.write(ctx, func(d *data) error {
+		if _, ok := d.budgets[budget.ID]; ok {
+			return sub.ErrConflict
+		}
+		d.budgets[budget.ID] = *copyBudget(*budget)
+		return nil
+	})
+}
+
+func (r *SubMemoryRepository) GetBudgetByID(ctx context.Context, id uuid.UUID) (*model.Budget, error) {
+	var budget model.Budget
+	err := r.read(func(d *data) error {
+		var ok bool
+		if budget, ok = d.budgets[id]; !ok || !r.budgetVisible(budget) {
+			return sub.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyBudget(budget), nil
+}
+
+// GetBudgets returns the budgets ordered by creation time.
+func (r *SubMemoryRepository) GetBudgets(ctx context.Context) ([]model.Budget, error) {
+	budgets := make([]model.Budget, 0)
+	err := r.read(func(d *data) error {
+		for _, budget := range d.budgets {
+			if r.budgetVisible(budget) {
+				budgets = append(budgets, *copyBudget(budget))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(budgets, func(a, b model.Budget) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return budgets, nil
+}
+
+// DeleteBudget removes the budget with its alerts.
+func (r *SubMemoryRepository) DeleteBudget(ctx context.Context, id uuid.UUID) error {
+	return r.write(ctx, func(d *data) error {
+		budget, ok := d.budgets[id]
+		if !ok || !r.budgetVisible(budget) {
+			return sub.ErrNotFound
+		}
+		delete(d.budgets, id)
+		for key := range d.alerts {
+			if key.budgetID == id {
+				delete(d.alerts, key)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SubMemoryRepository) AddBudgetAlert(ctx context.Context, alert *model.BudgetAlert) error {
+	if alert.ID == uuid.Nil {
+		alert.ID = uuid.New()
+	}
+	alert.CreatedAt = time.Now()
+	return r.write(ctx, func(d *data) error {
+		if _, ok := d.budgets[alert.BudgetID]; !ok {
+			return sub.ErrNotFound
+		}
+		key := budgetAlertKey{budgetID: alert.BudgetID, month: alert.Month, threshold: alert.Threshold}
+		if _, ok := d.alerts[key]; ok {
+			return sub.ErrConflict
+		}
+		d.alerts[key] = *alert
+		return nil
+	})
+}
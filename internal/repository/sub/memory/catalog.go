@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+
+	"github.com/google/uuid"
+)
+
+func copyService(service model.Service) *model.Service {
+	service.Aliases = slices.Clone(service.Aliases)
+	if service.Aliases == nil {
+		service.Aliases = []string{}
+	}
+	return &service
+}
+
+func (r *SubMemoryRepository) CreateService(ctx context.Context, service *model.Service) error {
+	if service.ID == uuid.Nil {
+		service.ID = uuid.New()
+	}
+	return r.write(ctx, func(d *data) error {
+		if _, ok := d.services[service.ID]; ok || nameTaken(d, service.Name, uuid.Nil) {
+			return sub.ErrConflict
+		}
+		return putService(d, service.ID, service)
+	})
+}
+
+func (r *SubMemoryRepository) GetServiceByID(ctx context.Context, id uuid.UUID) (*model.Service, error) {
+	var service model.Service
+	err := r.read(func(d *data) error {
+		var ok bool
+		if service, ok = d.services[id]; !ok {
+			return sub.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyService(service), nil
+}
+
+// GetServices returns the services ordered by name.
+func (r *SubMemoryRepository) GetServices(ctx context.Context) ([]model.Service, error) {
+	services := make([]model.Service, 0)
+	err := r.read(func(d *data) error {
+		for _, service := range d.services {
+			services = append(services, *copyService(service))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(services, func(a, b model.Service) int { return strings.Compare(a.Name, b.Name) })
+	return services, nil
+}
+
+// UpdateService replaces the service fields and aliases and renames its subscriptions.
+func (r *SubMemoryRepository) UpdateService(ctx context.Context, id uuid.UUID, service *model.Service) error {
+	return r.write(ctx, func(d *data) error {
+		current, ok := d.services[id]
+		if !ok {
+			return sub.ErrNotFound
+		}
+		if nameTaken(d, service.Name, id) {
+			return sub.ErrConflict
+		}
+		for _, alias := range current.Aliases {
+			delete(d.aliases, alias)
+		}
+		if err := putService(d, id, service); err != nil {
+			return err
+		}
+		for subID, s := range d.subs {
+			if s.ServiceID == id {
+				s.ServiceName = service.Name
+				d.subs[subID] = s
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteService fails with sub.ErrConflict while subscriptions use the service.
+func (r *SubMemoryRepository) DeleteService(ctx context.Context, id uuid.UUID) error {
+	return r.write(ctx, func(d *data) error {
+		service, ok := d.services[id]
+		if !ok {
+			return sub.ErrNotFound
+		}
+		for _, s := range d.subs {
+			if s.ServiceID == id {
+				return sub.ErrConflict
+			}
+		}
+		for _, alias := range service.Aliases {
+			delete(d.aliases, alias)
+		}
+		delete(d.services, id)
+		return nil
+	})
+}
+
+func (r *SubMemoryRepository) ResolveService(ctx context.Context, alias string) (*model.Service, error) {
+	var service model.Service
+	err := r.read(func(d *data) error {
+		id, ok := d.aliases[alias]
+		if !ok {
+			return sub.ErrNotFound
+		}
+		service = d.services[id]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyService(service), nil
+}
+
+func nameTaken(d *data, name string, except uuid.UUID) bool {
+	for id, service := range d.services {
+		if id != except && service.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// putService stores the service with its aliases, which must not be used by other services.
+func putService(d *data, id uuid.UUID, service *model.Service) error {
+	stored := copyService(*service)
+	stored.ID = id
+	slices.Sort(stored.Aliases)
+	stored.Aliases = slices.Compact(stored.Aliases)
+	for _, alias := range stored.Aliases {
+		if _, ok := d.aliases[alias]; ok {
+			return sub.ErrConflict
+		}
+		d.aliases[alias] = id
+	}
+	d.services[id] = *stored
+	return nil
+}
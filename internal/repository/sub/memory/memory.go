@@ -0,0 +1,314 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubMemoryRepository keeps everything in memory, for development and tests.
+// Every write and transaction works on a copy of the data that replaces it on success,
+// transactions run one at a time.
+type SubMemoryRepository struct {
+	db     *database
+	tx     *data
+	userID uuid.UUID
+	orgID  uuid.UUID
+}
+
+type database struct {
+	mu   sync.RWMutex
+	data *data
+}
+
+type data struct {
+	subs       map[uuid.UUID]model.Subscription
+	prices     map[uuid.UUID][]model.PriceChange
+	audit      []auditRow
+	services   map[uuid.UUID]model.Service
+	aliases    map[string]uuid.UUID
+	keys       map[uuid.UUID]apiKeyRow
+	budgets    map[uuid.UUID]model.Budget
+	alerts     map[budgetAlertKey]model.BudgetAlert
+	webhooks   map[uuid.UUID]model.Webhook
+	deliveries map[uuid.UUID]model.WebhookDelivery
+}
+
+// auditRow keeps the organization and owner of the subscription when the entry was written.
+type auditRow struct {
+	entry  model.AuditEntry
+	orgID  uuid.UUID
+	userID uuid.UUID
+}
+
+type apiKeyRow struct {
+	key  model.APIKey
+	hash string
+}
+
+func NewSubMemoryRepository() *SubMemoryRepository {
+	return &SubMemoryRepository{db: &database{data: &data{
+		subs:       make(map[uuid.UUID]model.Subscription),
+		prices:     make(map[uuid.UUID][]model.PriceChange),
+		services:   make(map[uuid.UUID]model.Service),
+		aliases:    make(map[string]uuid.UUID),
+		keys:       make(map[uuid.UUID]apiKeyRow),
+		budgets:    make(map[uuid.UUID]model.Budget),
+		alerts:     make(map[budgetAlertKey]model.BudgetAlert),
+		webhooks:   make(map[uuid.UUID]model.Webhook),
+		deliveries: make(map[uuid.UUID]model.WebhookDelivery),
+	}}}
+}
+
+// clone copies the maps, the stored values are replaced instead of modified.
+func (d *data) clone() *data {
+	return &data{
+		subs:       maps.Clone(d.subs),
+		prices:     maps.Clone(d.prices),
+		audit:      slices.Clone(d.audit),
+		services:   maps.Clone(d.services),
+		aliases:    maps.Clone(d.aliases),
+		keys:       maps.Clone(d.keys),
+		budgets:    maps.Clone(d.budgets),
+		alerts:     maps.Clone(d.alerts),
+		webhooks:   maps.Clone(d.webhooks),
+		deliveries: maps.Clone(d.deliveries),
+	}
+}
+
+func (r *SubMemoryRepository) WithTx(ctx context.Context, fn func(repo sub.SubscriptionRepository) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	txRepo := *r
+	txRepo.tx = r.db.data.clone()
+	if err := fn(&txRepo); err != nil {
+		return err
+	}
+	r.db.data = txRepo.tx
+	return nil
+}
+
+// read runs fn on the data visible to the repository.
+func (r *SubMemoryRepository) read(fn func(d *data) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	return fn(r.db.data)
+}
+
+// write runs fn on a copy of the data that replaces it if fn succeeds.
+func (r *SubMemoryRepository) write(ctx context.Context, fn func(d *data) error) error {
+	return r.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		return fn(repo.(*SubMemoryRepository).tx)
+	})
+}
+
+func (r *SubMemoryRepository) ForUser(userID uuid.UUID) sub.SubscriptionRepository {
+	scoped := *r
+	scoped.userID = userID
+	return &scoped
+}
+
+func (r *SubMemoryRepository) ForOrganization(orgID uuid.UUID) sub.SubscriptionRepository {
+	scoped := *r
+	scoped.orgID = orgID
+	return &scoped
+}
+
+// visible tells whether the subscription is in the repository scope.
+func (r *SubMemoryRepository) visible(s model.Subscription) bool {
+	return (r.orgID == uuid.Nil || s.OrganizationID == r.orgID) && (r.userID == uuid.Nil || s.UserID == r.userID)
+}
+
+// active returns the subscription in scope that isn't deleted.
+func (r *SubMemoryRepository) active(d *data, id uuid.UUID) (model.Subscription, error) {
+	s, ok := d.subs[id]
+	if !ok || !r.visible(s) || s.DeletedAt != nil {
+		return model.Subscription{}, sub.ErrNotFound
+	}
+	return s, nil
+}
+
+func copySub(s model.Subscription) *model.Subscription {
+	s.Tags = slices.Clone(s.Tags)
+	if s.Tags == nil {
+		s.Tags = []string{}
+	}
+	s.Pauses = slices.Clone(s.Pauses)
+	s.Promos = slices.Clone(s.Promos)
+	return &s
+}
+
+func (r *SubMemoryRepository) Create(ctx context.Context, s *model.Subscription) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	if r.orgID != uuid.Nil {
+		s.OrganizationID = r.orgID
+	}
+	return r.write(ctx, func(d *data) error {
+		if _, ok := d.subs[s.ID]; ok {
+			return sub.ErrConflict
+		}
+		stored := copySub(*s)
+		stored.DeletedAt, stored.Pauses = nil, nil
+		slices.Sort(stored.Tags)
+		sortPromos(stored.Promos)
+		d.subs[s.ID] = *stored
+		return nil
+	})
+}
+
+func (r *SubMemoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	var found model.Subscription
+	err := r.read(func(d *data) error {
+		var err error
+		found, err = r.active(d, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copySub(found), nil
+}
+
+func (r *SubMemoryRepository) Update(ctx context.Context, id uuid.UUID, s *model.Subscription) (*model.Subscription, error) {
+	var updated model.Subscription
+	err := r.write(ctx, func(d *data) error {
+		current, err := r.active(d, id)
+		if err != nil {
+			return err
+		}
+		current.ServiceID, current.ServiceName = s.ServiceID, s.ServiceName
+		current.Price, current.Currency, current.Category = s.Price, s.Currency, s.Category
+		current.BillingInterval = s.BillingInterval
+		current.UserID, current.StartDate, current.EndDate = s.UserID, s.StartDate, s.EndDate
+		current.Tags = slices.Sorted(slices.Values(s.Tags))
+		current.TrialEnd, current.Promos = s.TrialEnd, slices.Clone(s.Promos)
+		sortPromos(current.Promos)
+		d.subs[id] = current
+		updated = current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copySub(updated), nil
+}
+
+func (r *SubMemoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.write(ctx, func(d *data) error {
+		current, err := r.active(d, id)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		current.DeletedAt = &now
+		d.subs[id] = current
+		return nil
+	})
+}
+
+func (r *SubMemoryRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	var found model.Subscription
+	err := r.read(func(d *data) error {
+		s, ok := d.subs[id]
+		if !ok || !r.visible(s) || s.DeletedAt == nil {
+			return sub.ErrNotFound
+		}
+		found = s
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copySub(found), nil
+}
+
+func (r *SubMemoryRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	return r.write(ctx, func(d *data) error {
+		current, ok := d.subs[id]
+		if !ok || !r.visible(current) || current.DeletedAt == nil {
+			return sub.ErrNotFound
+		}
+		current.DeletedAt = nil
+		d.subs[id] = current
+		return nil
+	})
+}
+
+// Purge permanently removes subscriptions deleted before the given time with their price changes.
+func (r *SubMemoryRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := r.write(ctx, func(d *data) error {
+		for id, s := range d.subs {
+			if r.visible(s) && s.DeletedAt != nil && s.DeletedAt.Before(deletedBefore) {
+				delete(d.subs, id)
+				delete(d.prices, id)
+				purged++
+			}
+		}
+		return nil
+	})
+	return purged, err
+}
+
+// GetAll returns the subscriptions ordered by ID.
+func (r *SubMemoryRepository) GetAll(ctx context.Context, filter model.ListFilter) ([]model.Subscription, error) {
+	subs := make([]model.Subscription, 0)
+	err := r.read(func(d *data) error {
+		for _, s := range d.subs {
+			if !r.visible(s) || (s.DeletedAt != nil && !filter.IncludeDeleted) {
+				continue
+			}
+			if filter.Category != "" && (s.Category == nil || *s.Category != filter.Category) {
+				continue
+			}
+			if filter.TrialEndsIn != "" && (s.TrialEnd == nil || *s.TrialEnd != filter.TrialEndsIn) {
+				continue
+			}
+			if filter.EndsIn != "" && (s.EndDate == nil || *s.EndDate != filter.EndsIn) {
+				continue
+			}
+			if !hasTags(s, filter.Tags) {
+				continue
+			}
+			subs = append(subs, *copySub(s))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(subs, func(a, b model.Subscription) int {
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+	return subs, nil
+}
+
+func hasTags(s model.Subscription, tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(s.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortPromos(promos []model.Promo) {
+	slices.SortFunc(promos, func(a, b model.Promo) int { return compareMonths(a.StartDate, b.StartDate) })
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func newSub(userID uuid.UUID) *model.Subscription {
+	return &model.Subscription{ServiceID: uuid.New(), ServiceName: "Netflix", Price: 100, Currency: "RUB",
+		UserID: userID, StartDate: "01-2025", BillingInterval: 1}
+}
+
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+	rollback := errors.New("rollback")
+
+	tests := []struct {
+		name    string
+		fn      func(tx sub.SubscriptionRepository, s *model.Subscription) error
+		wantErr error
+		price   int
+		entries int
+	}{
+		{
+			name: "commit",
+			fn: func(tx sub.SubscriptionRepository, s *model.Subscription) error {
+				return updatePrice(ctx, tx, s, 200)
+			},
+			price:   200,
+			entries: 1,
+		},
+		{
+			name: "rollback",
+			fn: func(tx sub.SubscriptionRepository, s *model.Subscription) error {
+				if err := updatePrice(ctx, tx, s, 200); err != nil {
+					return err
+				}
+				return rollback
+			},
+			wantErr: rollback,
+			price:   100,
+		},
+		{
+			name: "nested transaction joins the outer one",
+			fn: func(tx sub.SubscriptionRepository, s *model.Subscription) error {
+				if err := tx.WithTx(ctx, func(inner sub.SubscriptionRepository) error {
+					return updatePrice(ctx, inner, s, 200)
+				}); err != nil {
+					return err
+				}
+				return rollback
+			},
+			wantErr: rollback,
+			price:   100,
+		},
+		{
+			name: "failed write rolls back earlier writes",
+			fn: func(tx sub.SubscriptionRepository, s *model.Subscription) error {
+				if err := updatePrice(ctx, tx, s, 200); err != nil {
+					return err
+				}
+				_, err := tx.Update(ctx, uuid.New(), newSub(s.UserID))
+				return err
+			},
+			wantErr: sub.ErrNotFound,
+			price:   100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewSubMemoryRepository()
+			s := newSub(uuid.New())
+			if err := repo.Create(ctx, s); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			err := repo.WithTx(ctx, func(tx sub.SubscriptionRepository) error { return tt.fn(tx, s) })
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithTx: err = %v, want %v", err, tt.wantErr)
+			}
+
+			got, err := repo.GetByID(ctx, s.ID)
+			if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+			if got.Price != tt.price {
+				t.Errorf("price = %d, want %d", got.Price, tt.price)
+			}
+			entries, err := repo.GetAuditEntries(ctx, model.AuditFilter{SubscriptionID: s.ID, Limit: 10})
+			if err != nil {
+				t.Fatalf("GetAuditEntries: %v", err)
+			}
+			if len(entries) != tt.entries {
+				t.Errorf("%d audit entries, want %d", len(entries), tt.entries)
+			}
+		})
+	}
+}
+
+// updatePrice changes the price and audits it, as the service does in one transaction.
+func updatePrice(ctx context.Context, repo sub.SubscriptionRepository, s *model.Subscription, price int) error {
+	update := *s
+	update.Price = price
+	if _, err := repo.Update(ctx, s.ID, &update); err != nil {
+		return err
+	}
+	return repo.AddAuditEntry(ctx, &model.AuditEntry{SubscriptionID: s.ID, Operation: model.OperationUpdate, Diff: []byte("{}")})
+}
+
+func TestWithTxKeepsScope(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSubMemoryRepository()
+	owner := uuid.New()
+	s := newSub(owner)
+	if err := repo.Create(ctx, s); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	err := repo.ForUser(uuid.New()).WithTx(ctx, func(tx sub.SubscriptionRepository) error {
+		_, err := tx.GetByID(ctx, s.ID)
+		return err
+	})
+	if !errors.Is(err, sub.ErrNotFound) {
+		t.Errorf("foreign read in a transaction: err = %v, want %v", err, sub.ErrNotFound)
+	}
+
+	err = repo.ForUser(owner).WithTx(ctx, func(tx sub.SubscriptionRepository) error {
+		return updatePrice(ctx, tx, s, 300)
+	})
+	if err != nil {
+		t.Fatalf("WithTx of the owner: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, s.ID); got.Price != 300 {
+		t.Errorf("price = %d, want 300", got.Price)
+	}
+}
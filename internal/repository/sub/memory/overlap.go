@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/pkg/period"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (r *SubMemoryRepository) FindOverlapping(ctx context.Context, s *model.Subscription) ([]uuid.UUID, error) {
+	start, end, err := monthRange(*s)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0)
+	err = r.read(func(d *data) error {
+		for id, other := range d.subs {
+			if id == s.ID || !r.visible(other) || other.DeletedAt != nil || other.UserID != s.UserID || other.ServiceID != s.ServiceID {
+				continue
+			}
+			otherStart, otherEnd, err := monthRange(other)
+			if err != nil {
+				return err
+			}
+			if !start.After(otherEnd) && !otherStart.After(end) {
+				ids = append(ids, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	return ids, nil
+}
+
+// monthRange returns the first and last month of the subscription, far in the future without end date.
+func monthRange(s model.Subscription) (time.Time, time.Time, error) {
+	start, err := period.Parse(s.StartDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, sub.ErrDatabase
+	}
+	end := time.Date(9999, time.December, 1, 0, 0, 0, 0, time.UTC)
+	if s.EndDate != nil {
+		if end, err = period.Parse(*s.EndDate); err != nil {
+			return time.Time{}, time.Time{}, sub.ErrDatabase
+		}
+	}
+	return start, end, nil
+}
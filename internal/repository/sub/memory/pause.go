@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/pkg/period"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (r *SubMemoryRepository) AddPause(ctx context.Context, pause *model.Pause) error {
+	if pause.ID == uuid.Nil {
+		pause.ID = uuid.New()
+	}
+	pause.CreatedAt = time.Now()
+	return r.write(ctx, func(d *data) error {
+		s, ok := d.subs[pause.SubscriptionID]
+		if !ok {
+			return sub.ErrNotFound
+		}
+		s.Pauses = append(slices.Clone(s.Pauses), *pause)
+		slices.SortFunc(s.Pauses, func(a, b model.Pause) int { return compareMonths(a.StartDate, b.StartDate) })
+		d.subs[s.ID] = s
+		return nil
+	})
+}
+
+func (r *SubMemoryRepository) UpdatePause(ctx context.Context, pause *model.Pause) error {
+	return r.write(ctx, func(d *data) error {
+		s, i, err := findPause(d, pause.SubscriptionID, pause.ID)
+		if err != nil {
+			return err
+		}
+		s.Pauses = slices.Clone(s.Pauses)
+		s.Pauses[i].EndDate = pause.EndDate
+		d.subs[s.ID] = s
+		return nil
+	})
+}
+
+func (r *SubMemoryRepository) DeletePause(ctx context.Context, subID, id uuid.UUID) error {
+	return r.write(ctx, func(d *data) error {
+		s, i, err := findPause(d, subID, id)
+		if err != nil {
+			return err
+		}
+		s.Pauses = slices.Delete(slices.Clone(s.Pauses), i, i+1)
+		d.subs[s.ID] = s
+		return nil
+	})
+}
+
+func findPause(d *data, subID, id uuid.UUID) (model.Subscription, int, error) {
+	s, ok := d.subs[subID]
+	if !ok {
+		return s, 0, sub.ErrNotFound
+	}
+	i := slices.IndexFunc(s.Pauses, func(p model.Pause) bool { return p.ID == id })
+	if i < 0 {
+		return s, 0, sub.ErrNotFound
+	}
+	return s, i, nil
+}
+
+// paused tells whether a pause of the subscription covers the month.
+func paused(s model.Subscription, month time.Time) bool {
+	for _, p := range s.Pauses {
+		start, err := period.Parse(p.StartDate)
+		if err != nil || start.After(month) {
+			continue
+		}
+		if p.EndDate == nil {
+			return true
+		}
+		if end, err := period.Parse(*p.EndDate); err == nil && !end.Before(month) {
+			return true
+		}
+	}
+	return false
+}
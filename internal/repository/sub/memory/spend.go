@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/pkg/period"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AddPriceChange replaces the price change of the subscription in the same month.
+func (r *SubMemoryRepository) AddPriceChange(ctx context.Context, change *model.PriceChange) error {
+	if change.ID == uuid.Nil {
+		change.ID = uuid.New()
+	}
+	return r.write(ctx, func(d *data) error {
+		if _, ok := d.subs[change.SubscriptionID]; !ok {
+			return sub.ErrNotFound
+		}
+		changes := slices.Clone(d.prices[change.SubscriptionID])
+		i := slices.IndexFunc(changes, func(c model.PriceChange) bool { return c.EffectiveFrom == change.EffectiveFrom })
+		if i >= 0 {
+			change.ID, change.CreatedAt = changes[i].ID, changes[i].CreatedAt
+			changes[i] = *change
+		} else {
+			change.CreatedAt = time.Now()
+			changes = append(changes, *change)
+		}
+		d.prices[change.SubscriptionID] = changes
+		return nil
+	})
+}
+
+// GetPriceChanges returns the price changes ordered by month, deleted subscriptions included.
+func (r *SubMemoryRepository) GetPriceChanges(ctx context.Context, subID uuid.UUID) ([]model.PriceChange, error) {
+	changes := make([]model.PriceChange, 0)
+	err := r.read(func(d *data) error {
+		if s, ok := d.subs[subID]; ok && r.visible(s) {
+			changes = append(changes, d.prices[subID]...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(changes, func(a, b model.PriceChange) int {
+		return compareMonths(a.EffectiveFrom, b.EffectiveFrom)
+	})
+	return changes, nil
+}
+
+func compareMonths(a, b string) int {
+	ta, _ := period.Parse(a)
+	tb, _ := period.Parse(b)
+	return ta.Compare(tb)
+}
+
+// GetMonthlySpend expands every subscription active in the period into its charge months, every
+// billing interval from its start other than paused ones, and sums the price in effect for each month
+// per month, currency and group.
+func (r *SubMemoryRepository) GetMonthlySpend(ctx context.Context, filter model.TotalFilter) ([]model.MonthlySpend, error) {
+	start, err := period.Parse(filter.StartDate)
+	if err != nil {
+		return nil, sub.ErrDatabase
+	}
+	end, err := period.Parse(filter.EndDate)
+	if err != nil {
+		return nil, sub.ErrDatabase
+	}
+
+	type key struct {
+		month    time.Time
+		currency string
+		group    string
+	}
+	sums := make(map[key]int)
+	err = r.read(func(d *data) error {
+		for _, s := range d.subs {
+			if !r.visible(s) || !matchesTotal(s, filter) {
+				continue
+			}
+			billingStart, err := period.Parse(s.StartDate)
+			if err != nil {
+				return sub.ErrDatabase
+			}
+			last := end
+			if s.EndDate != nil {
+				if last, err = period.Parse(*s.EndDate); err != nil {
+					return sub.ErrDatabase
+				}
+				last = minTime(last, end)
+			}
+			first := maxTime(billingStart, start)
+
+			groups := []string{""}
+			switch filter.GroupBy {
+			case model.GroupByCategory:
+				if s.Category != nil {
+					groups[0] = *s.Category
+				}
+			case model.GroupByTag:
+				if len(s.Tags) > 0 {
+					groups = s.Tags
+				}
+			}
+
+			for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+				if !due(s, billingStart, month) || paused(s, month) {
+					continue
+				}
+				price := priceAt(s, d.prices[s.ID], month)
+				for _, group := range groups {
+					sums[key{month, s.Currency, group}] += price
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	spend := make([]model.MonthlySpend, 0, len(sums))
+	for k, amount := range sums {
+		spend = append(spend, model.MonthlySpend{Month: period.Format(k.month), Currency: k.currency, Group: k.group, Amount: amount})
+	}
+	slices.SortFunc(spend, func(a, b model.MonthlySpend) int {
+		return cmp.Or(compareMonths(a.Month, b.Month), cmp.Compare(a.Currency, b.Currency), cmp.Compare(a.Group, b.Group))
+	})
+	return spend, nil
+}
+
+func matchesTotal(s model.Subscription, filter model.TotalFilter) bool {
+	switch {
+	case s.DeletedAt != nil && !filter.IncludeDeleted:
+		return false
+	case filter.UserID != uuid.Nil && s.UserID != filter.UserID:
+		return false
+	case filter.ServiceID != uuid.Nil && s.ServiceID != filter.ServiceID:
+		return false
+	case filter.ServiceName != "" && s.ServiceName != filter.ServiceName:
+		return false
+	case filter.Category != "" && (s.Category == nil || *s.Category != filter.Category):
+		return false
+	}
+	return hasTags(s, filter.Tags)
+}
+
+// due tells whether the subscription is charged in the month, every BillingInterval months from its start.
+func due(s model.Subscription, start, month time.Time) bool {
+	elapsed := (month.Year()-start.Year())*12 + int(month.Month()-start.Month())
+	return elapsed%max(s.BillingInterval, 1) == 0
+}
+
+// priceAt returns nothing during the trial, the promo price during a promo, otherwise
+// the price of the latest change effective in the month, or the subscription price.
+func priceAt(s model.Subscription, changes []model.PriceChange, month time.Time) int {
+	if s.TrialEnd != nil {
+		if trialEnd, err := period.Parse(*s.TrialEnd); err == nil && !month.After(trialEnd) {
+			return 0
+		}
+	}
+	for _, p := range s.Promos {
+		from, errFrom := period.Parse(p.StartDate)
+		until, errUntil := period.Parse(p.EndDate)
+		if errFrom == nil && errUntil == nil && !month.Before(from) && !month.After(until) {
+			return p.Price
+		}
+	}
+
+	price := s.Price
+	var effective time.Time
+	for _, c := range changes {
+		from, err := period.Parse(c.EffectiveFrom)
+		if err == nil && !from.After(month) && !from.Before(effective) {
+			price, effective = c.Price, from
+		}
+	}
+	return price
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
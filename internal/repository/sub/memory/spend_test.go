@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"subscription-service/internal/model"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// spendByMonth returns the spend of January to June 2025 per month.
+func spendByMonth(t *testing.T, repo *SubMemoryRepository) map[string]int {
+	t.Helper()
+	spend, err := repo.GetMonthlySpend(context.Background(), model.TotalFilter{StartDate: "01-2025", EndDate: "06-2025"})
+	if err != nil {
+		t.Fatalf("GetMonthlySpend: %v", err)
+	}
+	months := make(map[string]int)
+	for _, s := range spend {
+		months[s.Month] += s.Amount
+	}
+	return months
+}
+
+func charged(amount int, months ...string) map[string]int {
+	spend := make(map[string]int)
+	for _, month := range months {
+		spend[month] = amount
+	}
+	return spend
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestMonthlySpendSkipsPausedMonths(t *testing.T) {
+	type pause struct {
+		start string
+		end   *string
+	}
+	tests := []struct {
+		name     string
+		interval int
+		pauses   []pause
+		want     map[string]int
+	}{
+		{name: "no pause", want: charged(100, "01-2025", "02-2025", "03-2025", "04-2025", "05-2025", "06-2025")},
+		{name: "closed pause", pauses: []pause{{"02-2025", strPtr("03-2025")}},
+			want: charged(100, "01-2025", "04-2025", "05-2025", "06-2025")},
+		{name: "single month", pauses: []pause{{"06-2025", strPtr("06-2025")}},
+			want: charged(100, "01-2025", "02-2025", "03-2025", "04-2025", "05-2025")},
+		{name: "open pause", pauses: []pause{{"04-2025", nil}},
+			want: charged(100, "01-2025", "02-2025", "03-2025")},
+		{name: "pause before the period", pauses: []pause{{"11-2024", strPtr("01-2025")}},
+			want: charged(100, "02-2025", "03-2025", "04-2025", "05-2025", "06-2025")},
+		{name: "pause after the period", pauses: []pause{{"08-2025", nil}},
+			want: charged(100, "01-2025", "02-2025", "03-2025", "04-2025", "05-2025", "06-2025")},
+		{name: "two pauses", pauses: []pause{{"05-2025", strPtr("06-2025")}, {"02-2025", strPtr("02-2025")}},
+			want: charged(100, "01-2025", "03-2025", "04-2025")},
+		{name: "pause of a due month", interval: 3, pauses: []pause{{"04-2025", strPtr("04-2025")}},
+			want: charged(100, "01-2025")},
+		{name: "pause between due months", interval: 3, pauses: []pause{{"02-2025", strPtr("03-2025")}},
+			want: charged(100, "01-2025", "04-2025")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewSubMemoryRepository()
+			s := newSub(uuid.New())
+			s.BillingInterval = max(tt.interval, 1)
+			if err := repo.Create(ctx, s); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			for _, p := range tt.pauses {
+				if err := repo.AddPause(ctx, &model.Pause{SubscriptionID: s.ID, StartDate: p.start, EndDate: p.end}); err != nil {
+					t.Fatalf("AddPause: %v", err)
+				}
+			}
+
+			if got := spendByMonth(t, repo); !maps.Equal(got, tt.want) {
+				t.Errorf("spend = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonthlySpendEndsWithSubscription(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSubMemoryRepository()
+	early, late := newSub(uuid.New()), newSub(uuid.New())
+	early.EndDate = strPtr("02-2025")
+	late.StartDate = "05-2025"
+	for _, s := range []*model.Subscription{early, late} {
+		if err := repo.Create(ctx, s); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	got := spendByMonth(t, repo)
+	months := slices.Sorted(maps.Keys(got))
+	if want := []string{"01-2025", "02-2025", "05-2025", "06-2025"}; !slices.Equal(months, want) {
+		t.Errorf("charged months = %v, want %v", months, want)
+	}
+}
+
+func TestMonthlySpendTrialsAndPromos(t *testing.T) {
+	tests := []struct {
+		name     string
+		trialEnd *string
+		promos   []model.Promo
+		changes  map[string]int
+		want     []int
+	}{
+		{name: "regular price", want: []int{100, 100, 100, 100, 100, 100}},
+		{name: "trial", trialEnd: strPtr("02-2025"), want: []int{0, 0, 100, 100, 100, 100}},
+		{name: "promo", promos: []model.Promo{{Price: 50, StartDate: "03-2025", EndDate: "04-2025"}},
+			want: []int{100, 100, 50, 50, 100, 100}},
+		{name: "free promo", promos: []model.Promo{{Price: 0, StartDate: "06-2025", EndDate: "06-2025"}},
+			want: []int{100, 100, 100, 100, 100, 0}},
+		{name: "promo ahead of a price change", promos: []model.Promo{{Price: 50, StartDate: "03-2025", EndDate: "04-2025"}},
+			changes: map[string]int{"02-2025": 150}, want: []int{100, 150, 50, 50, 150, 150}},
+		{name: "trial ahead of a price change", trialEnd: strPtr("02-2025"), changes: map[string]int{"01-2025": 150},
+			want: []int{0, 0, 150, 150, 150, 150}},
+		{name: "trial, promo and price change", trialEnd: strPtr("01-2025"),
+			promos:  []model.Promo{{Price: 50, StartDate: "02-2025", EndDate: "02-2025"}, {Price: 70, StartDate: "05-2025", EndDate: "05-2025"}},
+			changes: map[string]int{"04-2025": 200}, want: []int{0, 50, 100, 200, 70, 200}},
+	}
+
+	months := []string{"01-2025", "02-2025", "03-2025", "04-2025", "05-2025", "06-2025"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewSubMemoryRepository()
+			s := newSub(uuid.New())
+			s.TrialEnd, s.Promos = tt.trialEnd, tt.promos
+			if err := repo.Create(ctx, s); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			for from, price := range tt.changes {
+				if err := repo.AddPriceChange(ctx, &model.PriceChange{SubscriptionID: s.ID, Price: price, EffectiveFrom: from}); err != nil {
+					t.Fatalf("AddPriceChange: %v", err)
+				}
+			}
+
+			want := make(map[string]int)
+			for i, amount := range tt.want {
+				want[months[i]] = amount
+			}
+			if got := spendByMonth(t, repo); !maps.Equal(got, want) {
+				t.Errorf("spend = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMonthlySpendPausedTrial(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSubMemoryRepository()
+	s := newSub(uuid.New())
+	s.TrialEnd = strPtr("02-2025")
+	if err := repo.Create(ctx, s); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// A pause doesn't extend the trial, it ends in its month regardless.
+	if err := repo.AddPause(ctx, &model.Pause{SubscriptionID: s.ID, StartDate: "02-2025", EndDate: strPtr("03-2025")}); err != nil {
+		t.Fatalf("AddPause: %v", err)
+	}
+
+	want := map[string]int{"01-2025": 0, "04-2025": 100, "05-2025": 100, "06-2025": 100}
+	if got := spendByMonth(t, repo); !maps.Equal(got, want) {
+		t.Errorf("spend = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func copyWebhook(webhook model.Webhook) *model.Webhook {
+	webhook.Events = slices.Clone(webhook.Events)
+	return &webhook
+}
+
+func copyDelivery(delivery model.WebhookDelivery) *model.WebhookDelivery {
+	delivery.Payload = slices.Clone(delivery.Payload)
+	return &delivery
+}
+
+// orgVisible tells whether data of the organization is in the repository scope,
+// webhooks aren't limited to users.
+func (r *SubMemoryRepository) orgVisible(orgID uuid.UUID) bool {
+	return r.orgID == uuid.Nil || orgID == r.orgID
+}
+
+func (r *SubMemoryRepository) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	if webhook.ID == uuid.Nil {
+		webhook.ID = uuid.New()
+	}
+	if r.orgID != uuid.Nil {
+		webhook.OrganizationID = r.orgID
+	}
+	webhook.CreatedAt = time.Now()
+	return r.write(ctx, func(d *data) error {
+		if _, ok := d.webhooks[webhook.ID]; ok {
+			return sub.ErrConflict
+		}
+		d.webhooks[webhook.ID] = *copyWebhook(*webhook)
+		return nil
+	})
+}
+
+func (r *SubMemoryRepository) GetWebhookByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	var webhook model.Webhook
+	err := r.read(func(d *data) error {
+		var ok bool
+		if webhook, ok = d.webhooks[id]; !ok || !r.orgVisible(webhook.OrganizationID) {
+			return sub.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyWebhook(webhook), nil
+}
+
+// GetWebhooks returns the webhooks ordered by creation time.
+func (r *SubMemoryRepository) GetWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	webhooks := make([]model.Webhook, 0)
+	err := r.read(func(d *data) error {
+		for _, webhook := range d.webhooks {
+			if r.orgVisible(webhook.OrganizationID) {
+				webhooks = append(webhooks, *copyWebhook(webhook))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(webhooks, func(a, b model.Webhook) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return webhooks, nil
+}
+
+// DeleteWebhook removes the webhook with its deliveries.
+func (r *SubMemoryRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	return r.write(ctx, func(d *data) error {
+		webhook, ok := d.webhooks[id]
+		if !ok || !r.orgVisible(webhook.OrganizationID) {
+			return sub.ErrNotFound
+		}
+		delete(d.webhooks, id)
+		for deliveryID, delivery := range d.deliveries {
+			if delivery.WebhookID == id {
+				delete(d.deliveries, deliveryID)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *SubMemoryRepository) AddDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if delivery.ID == uuid.Nil {
+		delivery.ID = uuid.New()
+	}
+	delivery.CreatedAt = time.Now()
+	return r.write(ctx, func(d *data) error {
+		if _, ok := d.webhooks[delivery.WebhookID]; !ok {
+			return sub.ErrNotFound
+		}
+		for _, queued := range d.deliveries {
+			if queued.WebhookID == delivery.WebhookID && queued.EventID == delivery.EventID {
+				return sub.ErrConflict
+			}
+		}
+		d.deliveries[delivery.ID] = *copyDelivery(*delivery)
+		return nil
+	})
+}
+
+func (r *SubMemoryRepository) GetDeliveryByID(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := r.read(func(d *data) error {
+		var ok bool
+		if delivery, ok = d.deliveries[id]; !ok || !r.orgVisible(delivery.OrganizationID) {
+			return sub.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyDelivery(delivery), nil
+}
+
+func (r *SubMemoryRepository) GetDeliveries(ctx context.Context, status string) ([]model.WebhookDelivery, error) {
+	deliveries := make([]model.WebhookDelivery, 0)
+	err := r.read(func(d *data) error {
+		for _, delivery := range d.deliveries {
+			if delivery.Status == status && r.orgVisible(delivery.OrganizationID) {
+				deliveries = append(deliveries, *copyDelivery(delivery))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(deliveries, func(a, b model.WebhookDelivery) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return deliveries, nil
+}
+
+func (r *SubMemoryRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	claimed := make([]model.WebhookDelivery, 0)
+	err := r.write(ctx, func(d *data) error {
+		for _, delivery := range d.deliveries {
+			if delivery.Status == model.DeliveryPending && !delivery.NextAttemptAt.After(now) && r.orgVisible(delivery.OrganizationID) {
+				claimed = append(claimed, delivery)
+			}
+		}
+		slices.SortFunc(claimed, func(a, b model.WebhookDelivery) int { return a.NextAttemptAt.Compare(b.NextAttemptAt) })
+		if len(claimed) > limit {
+			claimed = claimed[:limit]
+		}
+		for i := range claimed {
+			claimed[i].NextAttemptAt = now.Add(lease)
+			d.deliveries[claimed[i].ID] = claimed[i]
+			claimed[i] = *copyDelivery(claimed[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func (r *SubMemoryRepository) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.write(ctx, func(d *data) error {
+		current, ok := d.deliveries[delivery.ID]
+		if !ok || !r.orgVisible(current.OrganizationID) {
+			return sub.ErrNotFound
+		}
+		current.Status, current.Attempts = delivery.Status, delivery.Attempts
+		current.NextAttemptAt, current.LastError, current.DeliveredAt = delivery.NextAttemptAt, delivery.LastError, delivery.DeliveredAt
+		d.deliveries[delivery.ID] = current
+		return nil
+	})
+}
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"subscription-service/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = "id, name, prefix, scopes, user_id, organization_id, expires_at, last_used_at, revoked_at, created_at"
+
+func scanAPIKey(row rowScanner, key *model.APIKey) error {
+	var scopes pq.StringArray
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &scopes, &key.UserID, &key.OrganizationID,
+		&key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt)
+	if err != nil {
+		return err
+	}
+	key.Scopes = scopes
+	return nil
+}
+
+func (r *SubPostgresRepository) CreateAPIKey(ctx context.Context, key *model.APIKey, hash string) error {
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO api_keys (id, name, prefix, key_hash, scopes, user_id, organization_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING created_at`,
+		key.ID, key.Name, key.Prefix, hash, pq.StringArray(key.Scopes), key.UserID, key.OrganizationID, key.ExpiresAt,
+	).Scan(&key.CreatedAt)
+	if err != nil {
+		if isViolation(err, uniqueViolation) {
+			r.logger.Printf("API key %q already exists: %v", key.Prefix, err)
+			return ErrConflict
+		}
+		r.logger.Println("Failed to create API key:", err)
+		return ErrDatabase
+	}
+
+	r.logger.Printf("Successfully created API key with ID %s", key.ID)
+	return nil
+}
+
+// GetAPIKeys returns the keys of the repository's organization, all keys if it isn't scoped.
+func (r *SubPostgresRepository) GetAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	where, args := r.orgWhere(nil, nil)
+	rows, err := r.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys"+where+" ORDER BY created_at", args...)
+	if err != nil {
+		r.logger.Println("Failed to get API keys:", err)
+		return nil, ErrDatabase
+	}
+	defer rows.Close()
+
+	keys := make([]model.APIKey, 0)
+	for rows.Next() {
+		var key model.APIKey
+		if err = scanAPIKey(rows, &key); err != nil {
+			r.logger.Println("Failed to scan row while getting API keys:", err)
+			return nil, ErrDatabase
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Println("Failed iterating rows while getting API keys:", err)
+		return nil, ErrDatabase
+	}
+
+	r.logger.Printf("Successfully found %d API keys", len(keys))
+	return keys, nil
+}
+
+func (r *SubPostgresRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	key := &model.APIKey{}
+
+	row := r.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", hash)
+	if err := scanAPIKey(row, key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Println("API key not found")
+			return nil, ErrNotFound
+		}
+		r.logger.Println("Failed to get API key:", err)
+		return nil, ErrDatabase
+	}
+
+	return key, nil
+}
+
+func (r *SubPostgresRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	where, args := r.orgWhere([]string{"id = $2", "revoked_at IS NULL"}, []interface{}{at, id})
+	res, err := r.db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = $1"+where, args...)
+	if err != nil {
+		r.logger.Println("Failed to revoke API key:", err)
+		return ErrDatabase
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Println("Failed to get affected rows for API key revoke:", err)
+		return ErrDatabase
+	}
+	if rows == 0 {
+		r.logger.Printf("Active API key with ID %s not found", id)
+		return ErrNotFound
+	}
+
+	r.logger.Printf("Successfully revoked API key with ID %s", id)
+	return nil
+}
+
+// TouchAPIKey records the key use. The time is stored with minute precision
+// to avoid a write on every request.
+func (r *SubPostgresRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE api_keys SET last_used_at = $1 WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $1)",
+		at.Truncate(time.Minute), id)
+	if err != nil {
+		r.logger.Println("Failed to update API key last use:", err)
+		return ErrDatabase
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"subscription-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// AddAuditEntry records the entry with the organization and owner the subscription has after the change,
+// so that the history of previous owners stays theirs when it's reassigned.
+func (r *SubPostgresRepository) AddAuditEntry(ctx context.Context, entry *model.AuditEntry) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO audit_log (id, subscription_id, organization_id, user_id, actor, operation, request_id, before, after, diff)
+		SELECT $1, $2, organization_id, user_id, $3, $4, $5, $6, $7, $8 FROM subs WHERE id = $2 RETURNING created_at`,
+		entry.ID, entry.SubscriptionID, entry.Actor, entry.Operation, nullString(entry.RequestID),
+		nullJSON(entry.Before), nullJSON(entry.After), string(entry.Diff),
+	).Scan(&entry.CreatedAt)
+	if err != nil {
+		r.logger.Println("Failed to add audit entry:", err)
+		return ErrDatabase
+	}
+
+	r.logger.Printf("Successfully added audit entry %s for subscription with ID %s", entry.Operation, entry.SubscriptionID)
+	return nil
+}
+
+func (r *SubPostgresRepository) GetAuditEntries(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.SubscriptionID != uuid.Nil {
+		args = append(args, filter.SubscriptionID)
+		conditions = append(conditions, fmt.Sprintf("subscription_id = $%d", len(args)))
+	}
+	if filter.Actor != "" {
+		args = append(args, filter.Actor)
+		conditions = append(conditions, fmt.Sprintf("actor = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+
+	if r.orgID != uuid.Nil {
+		args = append(args, r.orgID)
+		conditions = append(conditions, fmt.Sprintf("organization_id = $%d", len(args)))
+	}
+	if r.userID != uuid.Nil {
+		args = append(args, r.userID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+
+	var queryBuilder strings.Builder
+	queryBuilder.WriteString("SELECT id, subscription_id, actor, operation, COALESCE(request_id, ''), before, after, diff, created_at FROM audit_log")
+	if len(conditions) > 0 {
+		queryBuilder.WriteString(" WHERE ")
+		queryBuilder.WriteString(strings.Join(conditions, " AND "))
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	queryBuilder.WriteString(fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d OFFSET $%d", len(args)-1, len(args)))
+
+	rows, err := r.db.QueryContext(ctx, queryBuilder.String(), args...)
+	if err != nil {
+		r.logger.Println("Failed to get audit entries:", err)
+		return nil, ErrDatabase
+	}
+	defer rows.Close()
+
+	entries := make([]model.AuditEntry, 0)
+	for rows.Next() {
+		var e model.AuditEntry
+		var before, after []byte
+		err = rows.Scan(&e.ID, &e.SubscriptionID, &e.Actor, &e.Operation, &e.RequestID, &before, &after, &e.Diff, &e.CreatedAt)
+		if err != nil {
+			r.logger.Println("Failed to scan row while getting audit entries:", err)
+			return nil, ErrDatabase
+		}
+		e.Before, e.After = before, after
+		entries = append(entries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Println("Failed iterating rows while getting audit entries:", err)
+		return nil, ErrDatabase
+	}
+
+	r.logger.Printf("Successfully found %d audit entries", len(entries))
+	return entries, nil
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// nullJSON passes JSON as text, lib/pq would send []byte as bytea.
+func nullJSON(data []byte) *string {
+	if data == nil {
+		return nil
+	}
+	s := string(data)
+	return &s
+}
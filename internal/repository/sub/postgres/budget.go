@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"subscription-service/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const budgetColumns = "id, name, user_id, category, amount, currency, thresholds, organization_id, created_at"
+
+func scanBudget(row rowScanner, budget *model.Budget) error {
+	var thresholds pq.Int64Array
+	err := row.Scan(&budget.ID, &budget.Name, &budget.UserID, &budget.Category, &budget.Amount, &budget.Currency,
+		&thresholds, &budget.OrganizationID, &budget.CreatedAt)
+	if err != nil {
+		return err
+	}
+	budget.Thresholds = make([]int, len(thresholds))
+	for i, threshold := range thresholds {
+		budget.Thresholds[i] = int(threshold)
+	}
+	return nil
+}
+
+func (r *SubPostgresRepository) CreateBudget(ctx context.Context, budget *model.Budget) error {
+	if budget.ID == uuid.Nil {
+		budget.ID = uuid.New()
+	}
+	if r.orgID != uuid.Nil {
+		budget.OrganizationID = r.orgID
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO budgets (id, name, user_id, category, amount, currency, thresholds, organization_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING created_at`,
+		budget.ID, budget.Name, budget.UserID, budget.Category, budget.Amount, budget.Currency,
+		pq.Array(budget.Thresholds), budget.OrganizationID,
+	).Scan(&budget.CreatedAt)
+	if err != nil {
+		if isViolation(err, uniqueViolation) {
+			r.logger.Printf("Budget with ID %s already exists: %v", budget.ID, err)
+			return ErrConflict
+		}
+		r.logger.Println("Failed to create budget:", err)
+		return ErrDatabase
+	}
+
+	r.logger.Printf("Successfully created budget with ID %s", budget.ID)
+	return nil
+}
+
+func (r *SubPostgresRepository) GetBudgetByID(ctx context.Context, id uuid.UUID) (*model.Budget, error) {
+	budget := &model.Budget{}
+
+	where, args := r.where([]string{"id = $1"}, []interface{}{id})
+	row := r.db.QueryRowContext(ctx, "SELECT "+budgetColumns+" FROM budgets"+where, args...)
+	if err := scanBudget(row, budget); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Printf("Budget with ID %s not found: %v", id, err)
+			return nil, ErrNotFound
+		}
+		r.logger.Printf("Failed to get budget with ID %s: %v", id, err)
+		return nil, ErrDatabase
+	}
+
+	return budget, nil
+}
+
+func (r *SubPostgresRepository) GetBudgets(ctx context.Context) ([]model.Budget, error) {
+	where, args := r.where(nil, nil)
+	rows, err := r.db.QueryContext(ctx, "SELECT "+budgetColumns+" FROM budgets"+where+" ORDER BY created_at", args...)
+	if err != nil {
+		r.logger.Println("Failed to get budgets:", err)
+		return nil, ErrDatabase
+	}
+	defer rows.Close()
+
+	budgets := make([]model.Budget, 0)
+	for rows.Next() {
+		var budget model.Budget
+		if err = scanBudget(rows, &budget); err != nil {
+			r.logger.Println("Failed to scan row while getting budgets:", err)
+			return nil, ErrDatabase
+		}
+		budgets = append(budgets, budget)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Println("Failed iterating rows while getting budgets:", err)
+		return nil, ErrDatabase
+	}
+
+	r.logger.Printf("Successfully found %d budgets", len(budgets))
+	return budgets, nil
+}
+
+// DeleteBudget removes the budget, its alerts are removed by the foreign key.
+func (r *SubPostgresRepository) DeleteBudget(ctx context.Context, id uuid.UUID) error {
+	where, args := r.where([]string{"id = $1"}, []interface{}{id})
+	res, err := r.db.ExecContext(ctx, "DELETE FROM budgets"+where, args...)
+	if err != nil {
+		r.logger.Println("Failed to delete budget:", err)
+		return ErrDatabase
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Println("Failed to get affected rows for budget delete:", err)
+		return ErrDatabase
+	}
+	if rows == 0 {
+		r.logger.Printf("Budget with ID %s not found", id)
+		return ErrNotFound
+	}
+
+	r.logger.Printf("Successfully deleted budget with ID %s", id)
+	return nil
+}
+
+func (r *SubPostgresRepository) AddBudgetAlert(ctx context.Context, alert *model.BudgetAlert) error {
+	if alert.ID == uuid.Nil {
+		alert.ID = uuid.New()
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO budget_alerts (id, budget_id, month, threshold, amount, spent, currency)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING created_at`,
+		alert.ID, alert.BudgetID, alert.Month, alert.Threshold, alert.Amount, alert.Spent, alert.Currency,
+	).Scan(&alert.CreatedAt)
+	if err != nil {
+		if isViolation(err, uniqueViolation) {
+			return ErrConflict
+		}
+		if isViolation(err, foreignKeyViolation) {
+			r.logger.Printf("Budget with ID %s not found: %v", alert.BudgetID, err)
+			return ErrNotFound
+		}
+		r.logger.Println("Failed to add budget alert:", err)
+		return ErrDatabase
+	}
+
+	r.logger.Printf("Budget %s reached %d%% in %s", alert.BudgetID, alert.Threshold, alert.Month)
+	return nil
+}
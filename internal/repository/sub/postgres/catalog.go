@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"subscription-service/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const serviceQuery = `SELECT s.id, s.name, s.category, s.default_price,
+	COALESCE(ARRAY_AGG(a.alias ORDER BY a.alias) FILTER (WHERE a.alias IS NOT NULL), '{}')
+	FROM services s LEFT JOIN service_aliases a ON a.service_id = s.id`
+
+func scanService(row rowScanner, service *model.Service) error {
+	var aliases pq.StringArray
+	if err := row.Scan(&service.ID, &service.Name, &service.Category, &service.DefaultPrice, &aliases); err != nil {
+		return err
+	}
+	service.Aliases = aliases
+	return nil
+}
+
+func (r *SubPostgresRepository) CreateService(ctx context.Context, service *model.Service) error {
+	if service.ID == uuid.Nil {
+		service.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO services (id, name, category, default_price) VALUES ($1, $2, $3, $4)",
+		service.ID, service.Name, service.Category, service.DefaultPrice,
+	)
+	if err != nil {
+		if isViolation(err, uniqueViolation) {
+			r.logger.Printf("Service %q already exists: %v", service.Name, err)
+			return ErrConflict
+		}
+		r.logger.Println("Failed to create service:", err)
+		return ErrDatabase
+	}
+
+	if err = r.addAliases(ctx, service.ID, service.Aliases); err != nil {
+		return err
+	}
+
+	r.logger.Printf("Successfully created service with ID %s", service.ID)
+	return nil
+}
+
+func (r *SubPostgresRepository) GetServiceByID(ctx context.Context, id uuid.UUID) (*model.Service, error) {
+	service := &model.Service{}
+
+	row := r.db.QueryRowContext(ctx, serviceQuery+" WHERE s.id = $1 GROUP BY s.id", id)
+	if err := scanService(row, service); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Printf("Service with ID %s not found: %v", id, err)
+			return nil, ErrNotFound
+		}
+		r.logger.Printf("Failed to get service with ID %s: %v", id, err)
+		return nil, ErrDatabase
+	}
+
+	r.logger.Printf("Successfully got service with ID %s", id)
+	return service, nil
+}
+
+func (r *SubPostgresRepository) GetServices(ctx context.Context) ([]model.Service, error) {
+	rows, err := r.db.QueryContext(ctx, serviceQuery+" GROUP BY s.id ORDER BY s.name")
+	if err != nil {
+		r.logger.Println("Failed to get services:", err)
+		return nil, ErrDatabase
+	}
+	defer rows.Close()
+
+	services := make([]model.Service, 0)
+	for rows.Next() {
+		var service model.Service
+		if err = scanService(rows, &service); err != nil {
+			r.logger.Println("Failed to scan row while getting services:", err)
+			return nil, ErrDatabase
+		}
+		services = append(services, service)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Println("Failed iterating rows while getting services:", err)
+		return nil, ErrDatabase
+	}
+
+	r.logger.Printf("Successfully found %d services", len(services))
+	return services, nil
+}
+
+// UpdateService replaces the service fields and aliases and renames its subscriptions.
+func (r *SubPostgresRepository) UpdateService(ctx context.Context, id uuid.UUID, service *model.Service) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE services SET name = $1, category = $2, default_price = $3 WHERE id = $4",
+		service.Name, service.Category, service.DefaultPrice, id,
+	)
+	if err != nil {
+		if isViolation(err, uniqueViolation) {
+			r.logger.Printf("Service %q already exists: %v", service.Name, err)
+			return ErrConflict
+		}
+		r.logger.Println("Failed to update service:", err)
+		return ErrDatabase
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Println("Failed to get affected rows for service update:", err)
+		return ErrDatabase
+	}
+	if rows == 0 {
+		r.logger.Printf("Service with ID %s not found", id)
+		return ErrNotFound
+	}
+
+	if _, err = r.db.ExecContext(ctx, "DELETE FROM service_aliases WHERE service_id = $1", id); err != nil {
+		r.logger.Println("Failed to delete service aliases:", err)
+		return ErrDatabase
+	}
+	if err = r.addAliases(ctx, id, service.Aliases); err != nil {
+		return err
+	}
+
+	if _, err = r.db.ExecContext(ctx, "UPDATE subs SET service_name = $1 WHERE service_id = $2", service.Name, id); err != nil {
+		r.logger.Println("Failed to rename subscriptions of service:", err)
+		return ErrDatabase
+	}
+
+	r.logger.Printf("Successfully updated service with ID %s", id)
+	return nil
+}
+
+func (r *SubPostgresRepository) DeleteService(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM services WHERE id = $1", id)
+	if err != nil {
+		if isViolation(err, foreignKeyViolation) {
+			r.logger.Printf("Service with ID %s is used by subscriptions: %v", id, err)
+			return ErrConflict
+		}
+		r.logger.Println("Failed to delete service:", err)
+		return ErrDatabase
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Println("Failed to get affected rows for service delete:", err)
+		return ErrDatabase
+	}
+	if rows == 0 {
+		r.logger.Printf("Service with ID %s not found", id)
+		return ErrNotFound
+	}
+
+	r.logger.Printf("Successfully deleted service with ID %s", id)
+	return nil
+}
+
+func (r *SubPostgresRepository) ResolveService(ctx context.Context, alias string) (*model.Service, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, "SELECT service_id FROM service_aliases WHERE alias = $1", alias).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Printf("Service alias %q not found", alias)
+			return nil, ErrNotFound
+		}
+		r.logger.Printf("Failed to resolve service alias %q: %v", alias, err)
+		return nil, ErrDatabase
+	}
+	return r.GetServiceByID(ctx, id)
+}
+
+func (r *SubPostgresRepository) addAliases(ctx context.Context, id uuid.UUID, aliases []string) error {
+	for _, alias := range aliases {
+		_, err := r.db.ExecContext(ctx, "INSERT INTO service_aliases (alias, service_id) VALUES ($1, $2)", alias, id)
+		if err != nil {
+			if isViolation(err, uniqueViolation) {
+				r.logger.Printf("Service alias %q is already used: %v", alias, err)
+				return ErrConflict
+			}
+			r.logger.Println("Failed to add service alias:", err)
+			return ErrDatabase
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"subscription-service/config"
+	"time"
+)
+
+const (
+	minConnectBackoff = 500 * time.Millisecond
+	maxConnectBackoff = 10 * time.Second
+)
+
+// defaultSSLMode is used without DATABASE_URL, which keeps the driver default otherwise.
+const defaultSSLMode = "disable"
+
+// dsn returns DATABASE_URL completed with the SSL settings and the password it doesn't set,
+// or a URL built from the separate DB_* settings.
+func dsn(cfg *config.Config) (string, error) {
+	if cfg.DatabaseURL != "" {
+		u, err := url.Parse(cfg.DatabaseURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid DATABASE_URL: %w", err)
+		}
+		return complete(u, cfg, cfg.DBSSLMode), nil
+	}
+
+	u := &url.URL{
+		Scheme: "postgres",
+		User:   url.User(cfg.DBUser),
+		Host:   net.JoinHostPort(cfg.DBHost, cfg.DBPort),
+		Path:   "/" + cfg.DBName,
+	}
+	sslMode := cfg.DBSSLMode
+	if sslMode == "" {
+		sslMode = defaultSSLMode
+	}
+	return complete(u, cfg, sslMode), nil
+}
+
+// replicaDSN returns DB_REPLICA_URL completed like DATABASE_URL.
+func replicaDSN(cfg *config.Config) (string, error) {
+	u, err := url.Parse(cfg.DBReplicaURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid DB_REPLICA_URL: %w", err)
+	}
+	return complete(u, cfg, cfg.DBSSLMode), nil
+}
+
+// complete adds the password and SSL settings the URL doesn't set.
+func complete(u *url.URL, cfg *config.Config, sslMode string) string {
+	if u.User != nil {
+		if _, ok := u.User.Password(); !ok && cfg.DBPassword != "" {
+			u.User = url.UserPassword(u.User.Username(), cfg.DBPassword)
+		}
+	}
+
+	query := u.Query()
+	if sslMode != "" && !query.Has("sslmode") {
+		query.Set("sslmode", sslMode)
+	}
+	if cfg.DBSSLRootCert != "" && !query.Has("sslrootcert") {
+		query.Set("sslrootcert", cfg.DBSSLRootCert)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// open creates a connection pool with the configured limits and waits for the database.
+func open(connStr string, cfg *config.Config, logger *log.Logger) (*sql.DB, error) {
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+
+	if err = connect(db, cfg.DBConnectTimeout, logger); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// connect waits for the database with exponential backoff until the timeout,
+// as the service may start before the database accepts connections.
+// Without a timeout it tries once.
+func connect(db *sql.DB, timeout time.Duration, logger *log.Logger) error {
+	if timeout <= 0 {
+		return db.Ping()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	backoff := minConnectBackoff
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		logger.Printf("PostgreSQL is not available, retrying in %s: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxConnectBackoff)
+	}
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"subscription-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// FindOverlapping compares the active_period of the subscriptions, months are inclusive
+// and a missing end date is unbounded.
+func (r *SubPostgresRepository) FindOverlapping(ctx context.Context, sub *model.Subscription) ([]uuid.UUID, error) {
+	where, args := r.where(
+		[]string{
+			"user_id = $1", "service_id = $2", "id <> $3", "deleted_at IS NULL",
+			"active_period && daterange(TO_DATE('01-' || $4, 'DD-MM-YYYY'), TO_DATE('01-' || $5, 'DD-MM-YYYY'), '[]')",
+		},
+		[]interface{}{sub.UserID, sub.ServiceID, sub.ID, sub.StartDate, sub.EndDate},
+	)
+	rows, err := r.db.QueryContext(ctx, "SELECT id FROM subs"+where+" ORDER BY id", args...)
+	if err != nil {
+		r.logger.Println("Failed to find overlapping subscriptions:", err)
+		return nil, ErrDatabase
+	}
+	defer rows.Close()
+
+	ids := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			r.logger.Println("Failed to scan row while finding overlapping subscriptions:", err)
+			return nil, ErrDatabase
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Println("Failed iterating rows while finding overlapping subscriptions:", err)
+		return nil, ErrDatabase
+	}
+
+	if len(ids) > 0 {
+		r.logger.Printf("Subscription with ID %s overlaps %d subscriptions", sub.ID, len(ids))
+	}
+	return ids, nil
+}
+
+// addOverlapConstraint adds the exclusion constraint rejecting overlapping subscriptions
+// of the same user and service unless it exists. It fails if existing subscriptions overlap.
+func (r *SubPostgresRepository) addOverlapConstraint(ctx context.Context) error {
+	_, err := r.conn.ExecContext(ctx, `DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'subs_no_overlap') THEN
+			ALTER TABLE subs ADD CONSTRAINT subs_no_overlap EXCLUDE USING gist
+				(organization_id WITH =, user_id WITH =, service_id WITH =, active_period WITH &&)
+				WHERE (deleted_at IS NULL);
+		END IF;
+	END $$`)
+	if err != nil {
+		r.logger.Println("Failed to add overlap constraint:", err)
+		return ErrDatabase
+	}
+	r.logger.Println("Overlapping subscriptions are rejected by the database")
+	return nil
+}
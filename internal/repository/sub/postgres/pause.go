@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"subscription-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// pausesColumn selects the pauses of a subscription as a JSON array ordered by month.
+const pausesColumn = `COALESCE((SELECT JSON_AGG(JSON_BUILD_OBJECT('id', p.id, 'subscription_id', p.subscription_id,
+	'start_date', p.start_date, 'end_date', p.end_date, 'created_at', p.created_at)
+	ORDER BY TO_DATE('01-' || p.start_date, 'DD-MM-YYYY')) FROM subscription_pauses p WHERE p.subscription_id = subs.id), '[]')`
+
+// notPausedCondition excludes the months m in which the subscription is paused.
+const notPausedCondition = `NOT EXISTS (SELECT 1 FROM subscription_pauses sp WHERE sp.subscription_id = subs.id
+	AND TO_DATE('01-' || sp.start_date, 'DD-MM-YYYY') <= m
+	AND (sp.end_date IS NULL OR TO_DATE('01-' || sp.end_date, 'DD-MM-YYYY') >= m))`
+
+func (r *SubPostgresRepository) AddPause(ctx context.Context, pause *model.Pause) error {
+	if pause.ID == uuid.Nil {
+		pause.ID = uuid.New()
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO subscription_pauses (id, subscription_id, start_date, end_date) VALUES ($1, $2, $3, $4) RETURNING created_at",
+		pause.ID, pause.SubscriptionID, pause.StartDate, pause.EndDate,
+	).Scan(&pause.CreatedAt)
+	if err != nil {
+		r.logger.Println("Failed to add pause:", err)
+		return ErrDatabase
+	}
+
+	r.writes.wrote(ctx)
+	r.logger.Printf("Successfully paused subscription with ID %s from %s", pause.SubscriptionID, pause.StartDate)
+	return nil
+}
+
+func (r *SubPostgresRepository) UpdatePause(ctx context.Context, pause *model.Pause) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE subscription_pauses SET end_date = $1 WHERE id = $2 AND subscription_id = $3",
+		pause.EndDate, pause.ID, pause.SubscriptionID,
+	)
+	if err != nil {
+		r.logger.Println("Failed to update pause:", err)
+		return ErrDatabase
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Println("Failed to get affected rows for pause update:", err)
+		return ErrDatabase
+	}
+	if rows == 0 {
+		r.logger.Printf("Pause with ID %s not found", pause.ID)
+		return ErrNotFound
+	}
+
+	r.writes.wrote(ctx)
+	r.logger.Printf("Successfully updated pause with ID %s", pause.ID)
+	return nil
+}
+
+func (r *SubPostgresRepository) DeletePause(ctx context.Context, subID, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM subscription_pauses WHERE id = $1 AND subscription_id = $2", id, subID)
+	if err != nil {
+		r.logger.Println("Failed to delete pause:", err)
+		return ErrDatabase
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Println("Failed to get affected rows for pause delete:", err)
+		return ErrDatabase
+	}
+	if rows == 0 {
+		r.logger.Printf("Pause with ID %s not found", id)
+		return ErrNotFound
+	}
+
+	r.writes.wrote(ctx)
+	r.logger.Printf("Successfully deleted pause with ID %s", id)
+	return nil
+}
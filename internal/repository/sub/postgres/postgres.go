@@ -1,80 +1,237 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"subscription-service/config"
 	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/pkg/period"
+	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var (
-	ErrNotFound = errors.New("requested item not found")
-	ErrDatabase = errors.New("database error")
+	ErrNotFound = sub.ErrNotFound
+	ErrConflict = sub.ErrConflict
+	ErrDatabase = sub.ErrDatabase
+	ErrOverlap  = sub.ErrOverlap
 )
 
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+	exclusionViolation  = "23P01"
+)
+
+func isViolation(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+const subColumns = "id, service_id, service_name, price, currency, billing_interval, category, " + tagsColumn + ", user_id, organization_id, start_date, end_date, trial_end, deleted_at, " + pausesColumn + ", " + promosColumn
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSub(row rowScanner, sub *model.Subscription) error {
+	var tags pq.StringArray
+	var pauses, promos []byte
+	err := row.Scan(&sub.ID, &sub.ServiceID, &sub.ServiceName, &sub.Price, &sub.Currency, &sub.BillingInterval, &sub.Category, &tags,
+		&sub.UserID, &sub.OrganizationID, &sub.StartDate, &sub.EndDate, &sub.TrialEnd, &sub.DeletedAt, &pauses, &promos)
+	if err != nil {
+		return err
+	}
+	sub.Tags = tags
+	if err = json.Unmarshal(pauses, &sub.Pauses); err != nil {
+		return err
+	}
+	return json.Unmarshal(promos, &sub.Promos)
+}
+
+// dbtx is implemented by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 type SubPostgresRepository struct {
-	db     *sql.DB
-	logger *log.Logger
+	conn   *sql.DB
+	db     dbtx
+	inTx   bool
+	userID uuid.UUID
+	orgID  uuid.UUID
+	// rls sets the organization of scoped transactions for the row-level security policies.
+	rls bool
+	// replica serves the heavy reads if configured, writes tells when to read from the primary instead.
+	replica *sql.DB
+	writes  *writeTracker
+	logger  *log.Logger
 }
 
 func NewSubPostgresRepository(cfg *config.Config, logger *log.Logger) (*SubPostgresRepository, error) {
-	connStr := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable",
-		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
-
-	var db *sql.DB
-	db, err := sql.Open("postgres", connStr)
+	connStr, err := dsn(cfg)
 	if err != nil {
-		logger.Println("Could not create database connection:", err)
+		logger.Println("Could not build database connection string:", err)
 		return nil, ErrDatabase
 	}
-
-	err = db.Ping()
+	db, err := open(connStr, cfg, logger)
 	if err != nil {
 		logger.Println("Could not connect to PostgreSQL:", err)
 		return nil, ErrDatabase
 	}
-
 	logger.Println("Connected to PostgreSQL")
-	return &SubPostgresRepository{db: db, logger: logger}, nil
+
+	repo := &SubPostgresRepository{conn: db, db: db, rls: cfg.TenantRLS, logger: logger}
+	if cfg.OverlapConstraint {
+		if err = repo.addOverlapConstraint(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.DBReplicaURL != "" {
+		if connStr, err = replicaDSN(cfg); err != nil {
+			logger.Println("Could not build replica connection string:", err)
+			return nil, ErrDatabase
+		}
+		if repo.replica, err = open(connStr, cfg, logger); err != nil {
+			logger.Println("Could not connect to PostgreSQL replica:", err)
+			return nil, ErrDatabase
+		}
+		repo.writes = newWriteTracker(cfg.DBReadYourWrites)
+		logger.Println("Connected to PostgreSQL replica")
+	}
+	return repo, nil
 }
 
-func (r *SubPostgresRepository) Create(sub *model.Subscription) error {
+func (r *SubPostgresRepository) WithTx(ctx context.Context, fn func(repo sub.SubscriptionRepository) error) error {
+	if r.inTx {
+		return fn(r)
+	}
+
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Println("Failed to begin transaction:", err)
+		return ErrDatabase
+	}
+
+	if r.rls && r.orgID != uuid.Nil {
+		if _, err = tx.ExecContext(ctx, "SELECT set_config('app.organization_id', $1, true)", r.orgID.String()); err != nil {
+			r.logger.Println("Failed to set transaction organization:", err)
+			if rbErr := tx.Rollback(); rbErr != nil {
+				r.logger.Println("Failed to rollback transaction:", rbErr)
+			}
+			return ErrDatabase
+		}
+	}
+
+	txRepo := *r
+	txRepo.db, txRepo.inTx = tx, true
+	if err = fn(&txRepo); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			r.logger.Println("Failed to rollback transaction:", rbErr)
+		}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		r.logger.Println("Failed to commit transaction:", err)
+		return ErrDatabase
+	}
+	return nil
+}
+
+// ForUser returns a repository whose queries only see subscriptions of the user.
+func (r *SubPostgresRepository) ForUser(userID uuid.UUID) sub.SubscriptionRepository {
+	scoped := *r
+	scoped.userID = userID
+	return &scoped
+}
+
+// ForOrganization returns a repository whose queries only see subscriptions of the organization.
+func (r *SubPostgresRepository) ForOrganization(orgID uuid.UUID) sub.SubscriptionRepository {
+	scoped := *r
+	scoped.orgID = orgID
+	return &scoped
+}
+
+// scope appends the organization and user conditions of a scoped repository to the query conditions.
+func (r *SubPostgresRepository) scope(conditions []string, args []interface{}) ([]string, []interface{}) {
+	if r.orgID != uuid.Nil {
+		args = append(args, r.orgID)
+		conditions = append(conditions, fmt.Sprintf("organization_id = $%d", len(args)))
+	}
+	if r.userID != uuid.Nil {
+		args = append(args, r.userID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	return conditions, args
+}
+
+// where joins the conditions of a query restricted to the repository scope.
+func (r *SubPostgresRepository) where(conditions []string, args []interface{}) (string, []interface{}) {
+	conditions, args = r.scope(conditions, args)
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r *SubPostgresRepository) Create(ctx context.Context, sub *model.Subscription) error {
 	if sub.ID == uuid.Nil {
 		sub.ID = uuid.New()
 	}
+	if r.orgID != uuid.Nil {
+		sub.OrganizationID = r.orgID
+	}
 
-	_, err := r.db.Exec(
-		"INSERT INTO subs (id, service_name, price, user_id, start_date, end_date) VALUES ($1, $2, $3, $4, $5, $6)",
-		sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate,
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO subs (id, service_id, service_name, price, currency, billing_interval, category, user_id, organization_id, start_date, end_date, trial_end) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)",
+		sub.ID, sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingInterval, sub.Category, sub.UserID, sub.OrganizationID, sub.StartDate, sub.EndDate, sub.TrialEnd,
 	)
 	if err != nil {
+		if isViolation(err, exclusionViolation) {
+			r.logger.Printf("Subscription with ID %s overlaps another one: %v", sub.ID, err)
+			return ErrOverlap
+		}
 		r.logger.Println("Failed to create subscription:", err)
 		return ErrDatabase
 	}
 
+	if err = r.setTags(ctx, sub.ID, sub.Tags); err != nil {
+		return err
+	}
+	if err = r.setPromos(ctx, sub.ID, sub.Promos); err != nil {
+		return err
+	}
+
+	r.writes.wrote(ctx)
 	r.logger.Printf("Successfully created subscription with ID %s", sub.ID)
 	return nil
 }
 
-func (r *SubPostgresRepository) GetByID(id uuid.UUID) (*model.Subscription, error) {
+func (r *SubPostgresRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
 	sub := &model.Subscription{}
 
-	err := r.db.
-		QueryRow("SELECT id, service_name, price, user_id, start_date, end_date FROM subs WHERE id = $1", id).
-		Scan(&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &sub.EndDate)
+	where, args := r.where([]string{"id = $1", "deleted_at IS NULL"}, []interface{}{id})
+	row := r.reader(ctx).QueryRowContext(ctx, "SELECT "+subColumns+" FROM subs"+where, args...)
+	err := scanSub(row, sub)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			r.logger.Printf("Subscription with ID %s not found: %v", sub.ID, err)
+			r.logger.Printf("Subscription with ID %s not found: %v", id, err)
 			return nil, ErrNotFound
 		}
-		r.logger.Printf("Failed to get subscription with ID %s: %v", sub.ID, err)
+		r.logger.Printf("Failed to get subscription with ID %s: %v", id, err)
 		return nil, ErrDatabase
 	}
 
@@ -82,20 +239,65 @@ func (r *SubPostgresRepository) GetByID(id uuid.UUID) (*model.Subscription, erro
 	return sub, nil
 }
 
-func (r *SubPostgresRepository) Update(id uuid.UUID, sub *model.Subscription) error {
-	res, err := r.db.Exec(
-		"UPDATE subs SET service_name = $1, price = $2, user_id = $3, start_date = $4, end_date = $5 WHERE id = $6",
-		sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, id,
+// Update changes the row and returns it in a single statement, the returned tags
+// and promos are replaced afterwards.
+func (r *SubPostgresRepository) Update(ctx context.Context, id uuid.UUID, sub *model.Subscription) (*model.Subscription, error) {
+	where, args := r.where(
+		[]string{"id = $11", "deleted_at IS NULL"},
+		[]interface{}{sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.Category, sub.UserID, sub.StartDate, sub.EndDate, sub.TrialEnd, sub.BillingInterval, id},
+	)
+	row := r.db.QueryRowContext(ctx,
+		"UPDATE subs SET service_id = $1, service_name = $2, price = $3, currency = $4, category = $5, user_id = $6, start_date = $7, end_date = $8, trial_end = $9, billing_interval = $10"+
+			where+" RETURNING "+subColumns,
+		args...,
 	)
 
-	if err != nil {
+	updated := &model.Subscription{}
+	if err := scanSub(row, updated); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Printf("Subscription with ID %s not found: %v", id, err)
+			return nil, ErrNotFound
+		}
+		if isViolation(err, exclusionViolation) {
+			r.logger.Printf("Subscription with ID %s overlaps another one: %v", id, err)
+			return nil, ErrOverlap
+		}
 		r.logger.Println("Failed to update subscription:", err)
+		return nil, ErrDatabase
+	}
+
+	if err := r.setTags(ctx, id, sub.Tags); err != nil {
+		return nil, err
+	}
+	updated.Tags = append([]string{}, sub.Tags...)
+	sort.Strings(updated.Tags)
+	if err := r.setPromos(ctx, id, sub.Promos); err != nil {
+		return nil, err
+	}
+	updated.Promos = append([]model.Promo{}, sub.Promos...)
+	sort.Slice(updated.Promos, func(i, j int) bool {
+		from, _ := period.Parse(updated.Promos[i].StartDate)
+		to, _ := period.Parse(updated.Promos[j].StartDate)
+		return from.Before(to)
+	})
+
+	r.writes.wrote(ctx)
+	r.logger.Printf("Successfully updated subscription with ID %s", id)
+	return updated, nil
+}
+
+// Delete marks the subscription as deleted, it is removed by Purge after the retention period.
+func (r *SubPostgresRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	where, args := r.where([]string{"id = $1", "deleted_at IS NULL"}, []interface{}{id})
+	res, err := r.db.ExecContext(ctx, "UPDATE subs SET deleted_at = NOW()"+where, args...)
+	if err != nil {
+		r.logger.Println("Failed to delete subscription", err)
 		return ErrDatabase
 	}
 
 	rows, err := res.RowsAffected()
 	if err != nil {
-		r.logger.Println("Failed to get affected rows for update:", err)
+		r.logger.Println("Failed to get affected rows for delete:", err)
 		return ErrDatabase
 	}
 	if rows == 0 {
@@ -103,33 +305,105 @@ func (r *SubPostgresRepository) Update(id uuid.UUID, sub *model.Subscription) er
 		return ErrNotFound
 	}
 
-	r.logger.Printf("Successfully updated subscription with ID %s", id)
+	r.writes.wrote(ctx)
+	r.logger.Printf("Successfully deleted subscription with ID %s", id)
 	return nil
 }
 
-func (r *SubPostgresRepository) Delete(id uuid.UUID) error {
-	res, err := r.db.Exec("DELETE FROM subs WHERE id = $1", id)
+// GetDeletedByID reads from the primary, since it precedes restoring the subscription.
+func (r *SubPostgresRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	sub := &model.Subscription{}
+
+	where, args := r.where([]string{"id = $1", "deleted_at IS NOT NULL"}, []interface{}{id})
+	row := r.db.QueryRowContext(ctx, "SELECT "+subColumns+" FROM subs"+where, args...)
+	if err := scanSub(row, sub); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Printf("Deleted subscription with ID %s not found: %v", id, err)
+			return nil, ErrNotFound
+		}
+		r.logger.Printf("Failed to get deleted subscription with ID %s: %v", id, err)
+		return nil, ErrDatabase
+	}
+	return sub, nil
+}
+
+func (r *SubPostgresRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	where, args := r.where([]string{"id = $1", "deleted_at IS NOT NULL"}, []interface{}{id})
+	res, err := r.db.ExecContext(ctx, "UPDATE subs SET deleted_at = NULL"+where, args...)
 	if err != nil {
-		r.logger.Println("Failed to delete subscription", err)
+		if isViolation(err, exclusionViolation) {
+			r.logger.Printf("Subscription with ID %s overlaps another one: %v", id, err)
+			return ErrOverlap
+		}
+		r.logger.Println("Failed to restore subscription", err)
 		return ErrDatabase
 	}
 
 	rows, err := res.RowsAffected()
 	if err != nil {
-		r.logger.Println("Failed to get affected rows for delete:", err)
+		r.logger.Println("Failed to get affected rows for restore:", err)
 		return ErrDatabase
 	}
 	if rows == 0 {
-		r.logger.Printf("Subscription with ID %s not found: %v", id, err)
+		r.logger.Printf("Deleted subscription with ID %s not found", id)
 		return ErrNotFound
 	}
 
-	r.logger.Printf("Successfully deleted subscription with ID %s", id)
+	r.writes.wrote(ctx)
+	r.logger.Printf("Successfully restored subscription with ID %s", id)
 	return nil
 }
 
-func (r *SubPostgresRepository) GetAll() ([]model.Subscription, error) {
-	rows, err := r.db.Query("SELECT id, service_name, price, user_id, start_date, end_date FROM subs")
+// Purge permanently removes subscriptions deleted before the given time.
+func (r *SubPostgresRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	where, args := r.where([]string{"deleted_at < $1"}, []interface{}{deletedBefore})
+	res, err := r.db.ExecContext(ctx, "DELETE FROM subs"+where, args...)
+	if err != nil {
+		r.logger.Println("Failed to purge deleted subscriptions:", err)
+		return 0, ErrDatabase
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Println("Failed to get affected rows for purge:", err)
+		return 0, ErrDatabase
+	}
+
+	r.logger.Printf("Successfully purged %d deleted subscriptions", rows)
+	return rows, nil
+}
+
+func (r *SubPostgresRepository) GetAll(ctx context.Context, filter model.ListFilter) ([]model.Subscription, error) {
+	var conditions []string
+	var args []interface{}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	if filter.Category != "" {
+		args = append(args, filter.Category)
+		conditions = append(conditions, fmt.Sprintf("category = $%d", len(args)))
+	}
+
+	if filter.TrialEndsIn != "" {
+		args = append(args, filter.TrialEndsIn)
+		conditions = append(conditions, fmt.Sprintf("trial_end = $%d", len(args)))
+	}
+
+	if filter.EndsIn != "" {
+		args = append(args, filter.EndsIn)
+		conditions = append(conditions, fmt.Sprintf("end_date = $%d", len(args)))
+	}
+
+	if len(filter.Tags) > 0 {
+		var condition string
+		condition, args = tagFilter(filter.Tags, args)
+		conditions = append(conditions, condition)
+	}
+
+	where, args := r.where(conditions, args)
+	rows, err := r.reader(ctx).QueryContext(ctx, "SELECT "+subColumns+" FROM subs"+where, args...)
 	if err != nil {
 		r.logger.Println("Failed to get all subscriptions:", err)
 		return nil, ErrDatabase
@@ -139,8 +413,7 @@ func (r *SubPostgresRepository) GetAll() ([]model.Subscription, error) {
 	subs := make([]model.Subscription, 0)
 	for rows.Next() {
 		var sub model.Subscription
-		err = rows.Scan(&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &sub.EndDate)
-		if err != nil {
+		if err = scanSub(rows, &sub); err != nil {
 			r.logger.Println("Failed to scan row while getting subscriptions", err)
 			return nil, ErrDatabase
 		}
@@ -157,40 +430,154 @@ func (r *SubPostgresRepository) GetAll() ([]model.Subscription, error) {
 	return subs, nil
 }
 
-func (r *SubPostgresRepository) GetTotalSum(start, end string, userID uuid.UUID, name string) (int, error) {
+func (r *SubPostgresRepository) AddPriceChange(ctx context.Context, change *model.PriceChange) error {
+	if change.ID == uuid.Nil {
+		change.ID = uuid.New()
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO subscription_prices (id, subscription_id, price, effective_from) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (subscription_id, effective_from) DO UPDATE SET price = EXCLUDED.price
+		RETURNING id, created_at`,
+		change.ID, change.SubscriptionID, change.Price, change.EffectiveFrom,
+	).Scan(&change.ID, &change.CreatedAt)
+	if err != nil {
+		r.logger.Println("Failed to add price change:", err)
+		return ErrDatabase
+	}
+
+	r.writes.wrote(ctx)
+	r.logger.Printf("Successfully scheduled price change for subscription with ID %s from %s",
+		change.SubscriptionID, change.EffectiveFrom)
+	return nil
+}
+
+func (r *SubPostgresRepository) GetPriceChanges(ctx context.Context, subID uuid.UUID) ([]model.PriceChange, error) {
+	where, args := r.where([]string{"id = $1"}, []interface{}{subID})
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, subscription_id, price, effective_from, created_at FROM subscription_prices
+		WHERE subscription_id IN (SELECT id FROM subs`+where+`) ORDER BY TO_DATE('01-' || effective_from, 'DD-MM-YYYY')`,
+		args...,
+	)
+	if err != nil {
+		r.logger.Println("Failed to get price changes:", err)
+		return nil, ErrDatabase
+	}
+	defer rows.Close()
+
+	changes := make([]model.PriceChange, 0)
+	for rows.Next() {
+		var c model.PriceChange
+		if err = rows.Scan(&c.ID, &c.SubscriptionID, &c.Price, &c.EffectiveFrom, &c.CreatedAt); err != nil {
+			r.logger.Println("Failed to scan row while getting price changes:", err)
+			return nil, ErrDatabase
+		}
+		changes = append(changes, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Println("Failed iterating rows while getting price changes:", err)
+		return nil, ErrDatabase
+	}
+
+	r.logger.Printf("Successfully found %d price changes for subscription with ID %s", len(changes), subID)
+	return changes, nil
+}
+
+// dueCondition keeps the months m in which the subscription is charged, every billing interval from its start.
+const dueCondition = `(EXTRACT(YEAR FROM AGE(m, TO_DATE('01-' || start_date, 'DD-MM-YYYY'))) * 12
+	+ EXTRACT(MONTH FROM AGE(m, TO_DATE('01-' || start_date, 'DD-MM-YYYY'))))::INT % billing_interval = 0`
+
+// GetMonthlySpend expands every subscription active in the period into its charge months, every
+// billing interval from its start other than paused ones, and sums the price in effect for each month
+// per month, currency and group.
+func (r *SubPostgresRepository) GetMonthlySpend(ctx context.Context, filter model.TotalFilter) ([]model.MonthlySpend, error) {
 	conditions := []string{
 		"TO_DATE('01-' || start_date, 'DD-MM-YYYY') <= TO_DATE('01-' || $1, 'DD-MM-YYYY')",
 		"(TO_DATE('01-' || end_date, 'DD-MM-YYYY') >= TO_DATE('01-' || $2, 'DD-MM-YYYY') OR end_date IS NULL)",
 	}
-	args := []interface{}{end, start}
+	args := []interface{}{filter.EndDate, filter.StartDate}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
 
-	if userID != uuid.Nil {
+	if filter.UserID != uuid.Nil {
 		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)+1))
-		args = append(args, userID)
+		args = append(args, filter.UserID)
+	}
+
+	if filter.ServiceID != uuid.Nil {
+		conditions = append(conditions, fmt.Sprintf("service_id = $%d", len(args)+1))
+		args = append(args, filter.ServiceID)
 	}
 
-	if name != "" {
+	if filter.ServiceName != "" {
 		conditions = append(conditions, fmt.Sprintf("service_name = $%d", len(args)+1))
-		args = append(args, name)
+		args = append(args, filter.ServiceName)
+	}
+
+	if filter.Category != "" {
+		conditions = append(conditions, fmt.Sprintf("category = $%d", len(args)+1))
+		args = append(args, filter.Category)
+	}
+
+	if len(filter.Tags) > 0 {
+		var condition string
+		condition, args = tagFilter(filter.Tags, args)
+		conditions = append(conditions, condition)
+	}
+
+	conditions = append(conditions, dueCondition, notPausedCondition)
+	conditions, args = r.scope(conditions, args)
+
+	group, groupJoin := "''", ""
+	switch filter.GroupBy {
+	case model.GroupByCategory:
+		group = "COALESCE(category, '')"
+	case model.GroupByTag:
+		group = "COALESCE(gt.name, '')"
+		groupJoin = "LEFT JOIN subscription_tags gst ON gst.subscription_id = subs.id LEFT JOIN tags gt ON gt.id = gst.tag_id "
 	}
 
 	var queryBuilder strings.Builder
-	queryBuilder.WriteString("SELECT SUM(price) FROM subs WHERE ")
+	queryBuilder.WriteString("SELECT TO_CHAR(m, 'MM-YYYY'), currency, " + group + ", SUM(" + chargeExpression + ") FROM subs ")
+	queryBuilder.WriteString(groupJoin)
+	queryBuilder.WriteString("CROSS JOIN LATERAL generate_series(")
+	queryBuilder.WriteString("GREATEST(TO_DATE('01-' || start_date, 'DD-MM-YYYY'), TO_DATE('01-' || $2, 'DD-MM-YYYY')), ")
+	queryBuilder.WriteString("LEAST(COALESCE(TO_DATE('01-' || end_date, 'DD-MM-YYYY'), TO_DATE('01-' || $1, 'DD-MM-YYYY')), TO_DATE('01-' || $1, 'DD-MM-YYYY')), ")
+	queryBuilder.WriteString("INTERVAL '1 month') AS m ")
+	queryBuilder.WriteString("LEFT JOIN LATERAL (SELECT price FROM subscription_prices ")
+	queryBuilder.WriteString("WHERE subscription_id = subs.id AND TO_DATE('01-' || effective_from, 'DD-MM-YYYY') <= m ")
+	queryBuilder.WriteString("ORDER BY TO_DATE('01-' || effective_from, 'DD-MM-YYYY') DESC LIMIT 1) AS pc ON TRUE ")
+	queryBuilder.WriteString(promoJoin)
+	queryBuilder.WriteString("WHERE ")
 	queryBuilder.WriteString(strings.Join(conditions, " AND "))
+	queryBuilder.WriteString(" GROUP BY m, currency, 3 ORDER BY m")
 
 	query := queryBuilder.String()
-	var totalSum sql.NullInt64
-	err := r.db.QueryRow(query, args...).Scan(&totalSum)
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
-		r.logger.Println("Error calculate total sum:", err)
-		return 0, ErrDatabase
+		r.logger.Println("Error calculate monthly spend:", err)
+		return nil, ErrDatabase
+	}
+	defer rows.Close()
+
+	spend := make([]model.MonthlySpend, 0)
+	for rows.Next() {
+		var s model.MonthlySpend
+		if err = rows.Scan(&s.Month, &s.Currency, &s.Group, &s.Amount); err != nil {
+			r.logger.Println("Failed to scan row while calculating monthly spend:", err)
+			return nil, ErrDatabase
+		}
+		spend = append(spend, s)
 	}
 
-	if !totalSum.Valid {
-		return 0, nil
+	if err = rows.Err(); err != nil {
+		r.logger.Println("Failed iterating rows while calculating monthly spend:", err)
+		return nil, ErrDatabase
 	}
 
-	res := int(totalSum.Int64)
-	r.logger.Println("Calculated total sum:", res)
-	return res, nil
+	r.logger.Printf("Calculated monthly spend for %d month/currency pairs", len(spend))
+	return spend, nil
 }
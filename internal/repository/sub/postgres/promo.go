@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"subscription-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// promosColumn selects the promos of a subscription as a JSON array ordered by month.
+const promosColumn = `COALESCE((SELECT JSON_AGG(JSON_BUILD_OBJECT('price', sp.price, 'start_date', sp.start_date, 'end_date', sp.end_date)
+	ORDER BY TO_DATE('01-' || sp.start_date, 'DD-MM-YYYY')) FROM subscription_promos sp WHERE sp.subscription_id = subs.id), '[]')`
+
+// chargeExpression is the price charged in month m: nothing during the trial, the promo price
+// during a promo, otherwise the latest price change or the subscription price.
+const chargeExpression = `CASE WHEN subs.trial_end IS NOT NULL AND m <= TO_DATE('01-' || subs.trial_end, 'DD-MM-YYYY') THEN 0
+	ELSE COALESCE(pr.price, pc.price, subs.price) END`
+
+// promoJoin joins the promo pr covering month m.
+const promoJoin = `LEFT JOIN LATERAL (SELECT price FROM subscription_promos WHERE subscription_id = subs.id
+	AND TO_DATE('01-' || start_date, 'DD-MM-YYYY') <= m AND TO_DATE('01-' || end_date, 'DD-MM-YYYY') >= m LIMIT 1) AS pr ON TRUE `
+
+// setPromos replaces the promos of a subscription.
+func (r *SubPostgresRepository) setPromos(ctx context.Context, subID uuid.UUID, promos []model.Promo) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM subscription_promos WHERE subscription_id = $1", subID); err != nil {
+		r.logger.Println("Failed to delete subscription promos:", err)
+		return ErrDatabase
+	}
+
+	for _, promo := range promos {
+		_, err := r.db.ExecContext(ctx,
+			"INSERT INTO subscription_promos (subscription_id, price, start_date, end_date) VALUES ($1, $2, $3, $4)",
+			subID, promo.Price, promo.StartDate, promo.EndDate,
+		)
+		if err != nil {
+			r.logger.Println("Failed to add subscription promo:", err)
+			return ErrDatabase
+		}
+	}
+	return nil
+}
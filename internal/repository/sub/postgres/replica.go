@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"subscription-service/internal/reqctx"
+	"sync"
+	"time"
+)
+
+// reader returns the connection for reads that may lag behind: the replica, unless
+// the repository is in a transaction, the request asks for the primary or the caller
+// wrote recently and should see its own writes.
+func (r *SubPostgresRepository) reader(ctx context.Context) dbtx {
+	if r.replica == nil || r.inTx || reqctx.PrimaryRead(ctx) || r.writes.recent(ctx) {
+		return r.db
+	}
+	return r.replica
+}
+
+// writeTracker remembers when callers last wrote. Callers are identified by the request actor,
+// anonymous ones by their address, so that they don't send each other to the primary.
+type writeTracker struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	writes  map[string]time.Time
+	checked time.Time
+}
+
+func newWriteTracker(window time.Duration) *writeTracker {
+	return &writeTracker{window: window, writes: make(map[string]time.Time)}
+}
+
+// wrote records a write of the caller, a nil tracker records nothing.
+func (t *writeTracker) wrote(ctx context.Context) {
+	if t == nil || t.window <= 0 {
+		return
+	}
+	caller, ok := callerKey(ctx)
+	if !ok {
+		return
+	}
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.writes[caller] = now
+	// Callers stop mattering once their window passed.
+	if now.Sub(t.checked) > t.window {
+		for caller, at := range t.writes {
+			if now.Sub(at) > t.window {
+				delete(t.writes, caller)
+			}
+		}
+		t.checked = now
+	}
+}
+
+// recent tells whether the caller wrote within the window.
+func (t *writeTracker) recent(ctx context.Context) bool {
+	if t == nil || t.window <= 0 {
+		return false
+	}
+	caller, ok := callerKey(ctx)
+	if !ok {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	at, ok := t.writes[caller]
+	return ok && time.Since(at) <= t.window
+}
+
+// callerKey identifies the caller by the actor or, for anonymous requests, the client address.
+// Requests with neither, like those of background workers, aren't tracked.
+func callerKey(ctx context.Context) (string, bool) {
+	if actor := reqctx.Actor(ctx); actor != reqctx.AnonymousActor {
+		return "actor:" + actor, true
+	}
+	if client := reqctx.Client(ctx); client != "" {
+		return "client:" + client, true
+	}
+	return "", false
+}
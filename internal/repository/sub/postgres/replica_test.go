@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"subscription-service/internal/reqctx"
+	"testing"
+	"time"
+)
+
+func TestWriteTracker(t *testing.T) {
+	client := func(addr string) context.Context {
+		return reqctx.WithClient(context.Background(), addr)
+	}
+	actor := func(name, addr string) context.Context {
+		return reqctx.WithActor(client(addr), name)
+	}
+
+	tracker := newWriteTracker(time.Minute)
+	tracker.wrote(client("192.0.2.1"))
+	tracker.wrote(actor("alice", "192.0.2.2"))
+	tracker.wrote(context.Background())
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{"anonymous writer", client("192.0.2.1"), true},
+		{"other anonymous client", client("192.0.2.3"), false},
+		{"actor", actor("alice", "192.0.2.9"), true},
+		{"other actor at the same address", actor("bob", "192.0.2.2"), false},
+		{"anonymous at the address of an actor", client("192.0.2.2"), false},
+		{"unidentified", context.Background(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tracker.recent(tt.ctx); got != tt.want {
+				t.Errorf("recent = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
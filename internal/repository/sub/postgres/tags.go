@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// tagsColumn selects the sorted tag names of a subscription as an array.
+const tagsColumn = `ARRAY(SELECT t.name FROM subscription_tags st JOIN tags t ON t.id = st.tag_id
+	WHERE st.subscription_id = subs.id ORDER BY t.name)`
+
+// setTags replaces the tags of a subscription, creating tags that don't exist yet.
+func (r *SubPostgresRepository) setTags(ctx context.Context, subID uuid.UUID, tags []string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM subscription_tags WHERE subscription_id = $1", subID); err != nil {
+		r.logger.Println("Failed to delete subscription tags:", err)
+		return ErrDatabase
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO tags (id, name) SELECT gen_random_uuid(), UNNEST($1::TEXT[]) ON CONFLICT (name) DO NOTHING",
+		pq.Array(tags),
+	)
+	if err != nil {
+		r.logger.Println("Failed to create tags:", err)
+		return ErrDatabase
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		"INSERT INTO subscription_tags (subscription_id, tag_id) SELECT $1, id FROM tags WHERE name = ANY($2)",
+		subID, pq.Array(tags),
+	)
+	if err != nil {
+		r.logger.Println("Failed to add subscription tags:", err)
+		return ErrDatabase
+	}
+	return nil
+}
+
+// tagFilter matches subscriptions that have every tag from tags.
+func tagFilter(tags []string, args []interface{}) (string, []interface{}) {
+	args = append(args, pq.Array(tags), len(tags))
+	return fmt.Sprintf(`(SELECT COUNT(*) FROM subscription_tags st JOIN tags t ON t.id = st.tag_id
+		WHERE st.subscription_id = subs.id AND t.name = ANY($%d)) = $%d`, len(args)-1, len(args)), args
+}
@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"subscription-service/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	webhookColumns  = "id, url, secret, events, organization_id, created_at"
+	deliveryColumns = "id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, organization_id, created_at, delivered_at"
+)
+
+func scanWebhook(row rowScanner, webhook *model.Webhook) error {
+	var events pq.StringArray
+	err := row.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &events, &webhook.OrganizationID, &webhook.CreatedAt)
+	if err != nil {
+		return err
+	}
+	webhook.Events = events
+	return nil
+}
+
+func scanDelivery(row rowScanner, delivery *model.WebhookDelivery) error {
+	return row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Payload,
+		&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastError, &delivery.OrganizationID,
+		&delivery.CreatedAt, &delivery.DeliveredAt)
+}
+
+// orgWhere joins the conditions restricted to the organization of the repository.
+// Webhooks and API keys belong to organizations only, so the user scope doesn't apply.
+func (r *SubPostgresRepository) orgWhere(conditions []string, args []interface{}) (string, []interface{}) {
+	if r.orgID != uuid.Nil {
+		args = append(args, r.orgID)
+		conditions = append(conditions, fmt.Sprintf("organization_id = $%d", len(args)))
+	}
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r *SubPostgresRepository) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	if webhook.ID == uuid.Nil {
+		webhook.ID = uuid.New()
+	}
+	if r.orgID != uuid.Nil {
+		webhook.OrganizationID = r.orgID
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO webhooks (id, url, secret, events, organization_id) VALUES ($1, $2, $3, $4, $5) RETURNING created_at",
+		webhook.ID, webhook.URL, webhook.Secret, pq.StringArray(webhook.Events), webhook.OrganizationID,
+	).Scan(&webhook.CreatedAt)
+	if err != nil {
+		if isViolation(err, uniqueViolation) {
+			r.logger.Printf("Webhook with ID %s already exists: %v", webhook.ID, err)
+			return ErrConflict
+		}
+		r.logger.Println("Failed to create webhook:", err)
+		return ErrDatabase
+	}
+
+	r.logger.Printf("Successfully created webhook with ID %s", webhook.ID)
+	return nil
+}
+
+func (r *SubPostgresRepository) GetWebhookByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	webhook := &model.Webhook{}
+
+	where, args := r.orgWhere([]string{"id = $1"}, []interface{}{id})
+	row := r.db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks"+where, args...)
+	if err := scanWebhook(row, webhook); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Printf("Webhook with ID %s not found: %v", id, err)
+			return nil, ErrNotFound
+		}
+		r.logger.Printf("Failed to get webhook with ID %s: %v", id, err)
+		return nil, ErrDatabase
+	}
+
+	return webhook, nil
+}
+
+func (r *SubPostgresRepository) GetWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	where, args := r.orgWhere(nil, nil)
+	rows, err := r.db.QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhooks"+where+" ORDER BY created_at", args...)
+	if err != nil {
+		r.logger.Println("Failed to get webhooks:", err)
+		return nil, ErrDatabase
+	}
+	defer rows.Close()
+
+	webhooks := make([]model.Webhook, 0)
+	for rows.Next() {
+		var webhook model.Webhook
+		if err = scanWebhook(rows, &webhook); err != nil {
+			r.logger.Println("Failed to scan row while getting webhooks:", err)
+			return nil, ErrDatabase
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Println("Failed iterating rows while getting webhooks:", err)
+		return nil, ErrDatabase
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook removes the webhook, its deliveries are removed by the foreign key.
+func (r *SubPostgresRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	where, args := r.orgWhere([]string{"id = $1"}, []interface{}{id})
+	res, err := r.db.ExecContext(ctx, "DELETE FROM webhooks"+where, args...)
+	if err != nil {
+		r.logger.Println("Failed to delete webhook:", err)
+		return ErrDatabase
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Println("Failed to get affected rows for webhook delete:", err)
+		return ErrDatabase
+	}
+	if rows == 0 {
+		r.logger.Printf("Webhook with ID %s not found", id)
+		return ErrNotFound
+	}
+
+	r.logger.Printf("Successfully deleted webhook with ID %s", id)
+	return nil
+}
+
+func (r *SubPostgresRepository) AddDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if delivery.ID == uuid.Nil {
+		delivery.ID = uuid.New()
+	}
+
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, organization_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING created_at`,
+		delivery.ID, delivery.WebhookID, delivery.EventID, delivery.EventType, []byte(delivery.Payload),
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.OrganizationID,
+	).Scan(&delivery.CreatedAt)
+	if err != nil {
+		if isViolation(err, uniqueViolation) {
+			return ErrConflict
+		}
+		if isViolation(err, foreignKeyViolation) {
+			r.logger.Printf("Webhook with ID %s not found: %v", delivery.WebhookID, err)
+			return ErrNotFound
+		}
+		r.logger.Println("Failed to queue webhook delivery:", err)
+		return ErrDatabase
+	}
+
+	r.writes.wrote(ctx)
+	return nil
+}
+
+func (r *SubPostgresRepository) GetDeliveryByID(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{}
+
+	where, args := r.orgWhere([]string{"id = $1"}, []interface{}{id})
+	row := r.db.QueryRowContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries"+where, args...)
+	if err := scanDelivery(row, delivery); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.logger.Printf("Webhook delivery with ID %s not found: %v", id, err)
+			return nil, ErrNotFound
+		}
+		r.logger.Printf("Failed to get webhook delivery with ID %s: %v", id, err)
+		return nil, ErrDatabase
+	}
+
+	return delivery, nil
+}
+
+func (r *SubPostgresRepository) GetDeliveries(ctx context.Context, status string) ([]model.WebhookDelivery, error) {
+	where, args := r.orgWhere([]string{"status = $1"}, []interface{}{status})
+	rows, err := r.db.QueryContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries"+where+" ORDER BY created_at", args...)
+	if err != nil {
+		r.logger.Println("Failed to get webhook deliveries:", err)
+		return nil, ErrDatabase
+	}
+	return r.scanDeliveries(rows)
+}
+
+// ClaimDeliveries postpones the due deliveries in a single statement, skipping rows locked
+// by another instance claiming at the same time.
+func (r *SubPostgresRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	where, args := r.orgWhere(
+		[]string{"status = $1", "next_attempt_at <= $2"},
+		[]interface{}{model.DeliveryPending, now, now.Add(lease), limit},
+	)
+	rows, err := r.db.QueryContext(ctx,
+		"UPDATE webhook_deliveries SET next_attempt_at = $3 WHERE id IN (SELECT id FROM webhook_deliveries"+where+
+			" ORDER BY next_attempt_at LIMIT $4 FOR UPDATE SKIP LOCKED) RETURNING "+deliveryColumns,
+		args...,
+	)
+	if err != nil {
+		r.logger.Println("Failed to claim webhook deliveries:", err)
+		return nil, ErrDatabase
+	}
+	return r.scanDeliveries(rows)
+}
+
+func (r *SubPostgresRepository) scanDeliveries(rows *sql.Rows) ([]model.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := make([]model.WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery model.WebhookDelivery
+		if err := scanDelivery(rows, &delivery); err != nil {
+			r.logger.Println("Failed to scan row while getting webhook deliveries:", err)
+			return nil, ErrDatabase
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Println("Failed iterating rows while getting webhook deliveries:", err)
+		return nil, ErrDatabase
+	}
+
+	return deliveries, nil
+}
+
+func (r *SubPostgresRepository) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	where, args := r.orgWhere(
+		[]string{"id = $6"},
+		[]interface{}{delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.DeliveredAt, delivery.ID},
+	)
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, delivered_at = $5"+where,
+		args...,
+	)
+	if err != nil {
+		r.logger.Println("Failed to update webhook delivery:", err)
+		return ErrDatabase
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Println("Failed to get affected rows for webhook delivery update:", err)
+		return ErrDatabase
+	}
+	if rows == 0 {
+		r.logger.Printf("Webhook delivery with ID %s not found", delivery.ID)
+		return ErrNotFound
+	}
+	return nil
+}
@@ -1,16 +1,56 @@
 package sub
 
 import (
+	"context"
+	"errors"
 	"subscription-service/internal/model"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// Errors returned by every repository implementation.
+var (
+	ErrNotFound = errors.New("requested item not found")
+	ErrConflict = errors.New("conflicting item already exists")
+	ErrDatabase = errors.New("database error")
+	// ErrOverlap is returned if the database rejects overlapping subscriptions.
+	ErrOverlap = errors.New("subscription overlaps another one of the same user and service")
+)
+
 type SubscriptionRepository interface {
-	Create(sub *model.Subscription) error
-	GetByID(id uuid.UUID) (*model.Subscription, error)
-	Update(id uuid.UUID, sub *model.Subscription) error
-	Delete(id uuid.UUID) error
-	GetAll() ([]model.Subscription, error)
-	GetTotalSum(start, end string, userID uuid.UUID, name string) (int, error)
+	CatalogRepository
+	APIKeyRepository
+	BudgetRepository
+	WebhookRepository
+
+	Create(ctx context.Context, sub *model.Subscription) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error)
+	// Update replaces the subscription fields and tags and returns the updated subscription.
+	Update(ctx context.Context, id uuid.UUID, sub *model.Subscription) (*model.Subscription, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	// GetDeletedByID returns the subscription if it's deleted and not purged yet.
+	GetDeletedByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error)
+	Restore(ctx context.Context, id uuid.UUID) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	GetAll(ctx context.Context, filter model.ListFilter) ([]model.Subscription, error)
+	// FindOverlapping returns the IDs of other subscriptions of the same user and service
+	// that aren't deleted and share a month with the subscription period.
+	FindOverlapping(ctx context.Context, sub *model.Subscription) ([]uuid.UUID, error)
+	AddPriceChange(ctx context.Context, change *model.PriceChange) error
+	GetPriceChanges(ctx context.Context, subID uuid.UUID) ([]model.PriceChange, error)
+	AddPause(ctx context.Context, pause *model.Pause) error
+	// UpdatePause sets the last paused month of the pause.
+	UpdatePause(ctx context.Context, pause *model.Pause) error
+	DeletePause(ctx context.Context, subID, id uuid.UUID) error
+	GetMonthlySpend(ctx context.Context, filter model.TotalFilter) ([]model.MonthlySpend, error)
+	AddAuditEntry(ctx context.Context, entry *model.AuditEntry) error
+	GetAuditEntries(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error)
+	// ForUser returns a repository scoped to subscriptions of the user.
+	ForUser(userID uuid.UUID) SubscriptionRepository
+	// ForOrganization returns a repository scoped to subscriptions of the organization.
+	ForOrganization(orgID uuid.UUID) SubscriptionRepository
+	// WithTx runs fn with a repository bound to a single transaction,
+	// committed if fn returns nil and rolled back otherwise.
+	WithTx(ctx context.Context, fn func(repo SubscriptionRepository) error) error
 }
@@ -0,0 +1,28 @@
+package sub
+
+import (
+	"context"
+	"subscription-service/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookRepository stores webhooks and the queue of their deliveries.
+// Webhooks belong to organizations, a user-scoped repository sees the ones of its organization.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+	GetWebhookByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	// AddDelivery queues the delivery, ErrConflict if the event is already queued for the webhook.
+	AddDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	GetDeliveryByID(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error)
+	// GetDeliveries returns the deliveries with the status, oldest first.
+	GetDeliveries(ctx context.Context, status string) ([]model.WebhookDelivery, error)
+	// ClaimDeliveries returns up to limit pending deliveries due at now and postpones them by lease,
+	// so that other instances don't send them at the same time.
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error)
+	// UpdateDelivery saves the status, attempts, next attempt, last error and delivery time.
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+}
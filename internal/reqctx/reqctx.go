@@ -0,0 +1,71 @@
+package reqctx
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// AnonymousActor is recorded when a request does not identify its caller.
+const AnonymousActor = "anonymous"
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	actorKey
+	organizationKey
+	primaryReadKey
+	clientKey
+)
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+func WithOrganization(ctx context.Context, orgID uuid.UUID) context.Context {
+	return context.WithValue(ctx, organizationKey, orgID)
+}
+
+// Organization returns the tenant the request is limited to.
+func Organization(ctx context.Context) (uuid.UUID, bool) {
+	orgID, ok := ctx.Value(organizationKey).(uuid.UUID)
+	return orgID, ok && orgID != uuid.Nil
+}
+
+// WithPrimaryRead asks for reads from the primary database, so the request sees
+// writes not yet replicated.
+func WithPrimaryRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadKey, true)
+}
+
+func PrimaryRead(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadKey).(bool)
+	return primary
+}
+
+// WithClient stores the network address of the client, without the port.
+func WithClient(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, clientKey, addr)
+}
+
+func Client(ctx context.Context) string {
+	addr, _ := ctx.Value(clientKey).(string)
+	return addr
+}
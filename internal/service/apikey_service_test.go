@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/internal/repository/sub/memory"
+	"subscription-service/internal/reqctx"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAPIKeyServiceOrganizationScope(t *testing.T) {
+	svc := NewAPIKeyService(memory.NewSubMemoryRepository(), Scope{MultiTenant: true})
+	org, other := uuid.New(), uuid.New()
+	ctx := reqctx.WithOrganization(context.Background(), org)
+	otherCtx := reqctx.WithOrganization(context.Background(), other)
+
+	created, err := svc.Create(ctx, model.APIKeyRequest{Name: "batch", Scopes: []string{"user"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.OrganizationID == nil || *created.OrganizationID != org {
+		t.Errorf("organization = %v, want %s", created.OrganizationID, org)
+	}
+	claims, err := svc.VerifyAPIKey(context.Background(), created.Key)
+	if err != nil {
+		t.Fatalf("VerifyAPIKey: %v", err)
+	}
+	if claims.OrganizationID != org.String() {
+		t.Errorf("claims organization = %q, want %s", claims.OrganizationID, org)
+	}
+
+	if _, err = svc.Create(ctx, model.APIKeyRequest{Name: "foreign", Scopes: []string{"user"}, OrganizationID: &other}); !errors.Is(err, ErrForeignOrganization) {
+		t.Errorf("Create for another organization: err = %v, want %v", err, ErrForeignOrganization)
+	}
+	if _, err = svc.Create(context.Background(), model.APIKeyRequest{Name: "none", Scopes: []string{"user"}}); !errors.Is(err, ErrNoOrganization) {
+		t.Errorf("Create without organization: err = %v, want %v", err, ErrNoOrganization)
+	}
+
+	if keys, err := svc.GetAll(otherCtx); err != nil || len(keys) != 0 {
+		t.Errorf("GetAll of another organization = %v, %v, want no keys", keys, err)
+	}
+	if keys, err := svc.GetAll(ctx); err != nil || len(keys) != 1 {
+		t.Errorf("GetAll = %v, %v, want the created key", keys, err)
+	}
+
+	if err = svc.Revoke(otherCtx, created.ID); !errors.Is(err, sub.ErrNotFound) {
+		t.Errorf("Revoke by another organization: err = %v, want %v", err, sub.ErrNotFound)
+	}
+	if err = svc.Revoke(ctx, created.ID); err != nil {
+		t.Errorf("Revoke: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/internal/reqctx"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type fieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// newAuditEntry snapshots the subscription before and after an operation.
+// Either side is nil for creations and deletions.
+func newAuditEntry(ctx context.Context, op string, subID uuid.UUID, before, after *model.Subscription) (*model.AuditEntry, error) {
+	beforeJSON, beforeFields, err := snapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	afterJSON, afterFields, err := snapshot(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make(map[string]fieldChange)
+	for field, value := range afterFields {
+		if old, ok := beforeFields[field]; !ok || !reflect.DeepEqual(old, value) {
+			diff[field] = fieldChange{Old: old, New: value}
+		}
+	}
+	for field, old := range beforeFields {
+		if _, ok := afterFields[field]; !ok {
+			diff[field] = fieldChange{Old: old}
+		}
+	}
+	diffJSON, err := json.Marshal(diff)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AuditEntry{
+		SubscriptionID: subID,
+		Actor:          reqctx.Actor(ctx),
+		Operation:      op,
+		RequestID:      reqctx.RequestID(ctx),
+		Before:         beforeJSON,
+		After:          afterJSON,
+		Diff:           diffJSON,
+	}, nil
+}
+
+func snapshot(sub *model.Subscription) (json.RawMessage, map[string]any, error) {
+	if sub == nil {
+		return nil, nil, nil
+	}
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fields map[string]any
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, nil, err
+	}
+	return data, fields, nil
+}
+
+func (s *SubService) GetAuditEntries(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	filter.Limit = min(filter.Limit, maxAuditLimit)
+
+	var entries []model.AuditEntry
+	err := s.read(ctx, func(repo sub.SubscriptionRepository) error {
+		var err error
+		entries, err = repo.GetAuditEntries(ctx, filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetHistory returns audit entries of a subscription, including deleted ones.
+func (s *SubService) GetHistory(ctx context.Context, subID uuid.UUID, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	filter.SubscriptionID = subID
+	return s.GetAuditEntries(ctx, filter)
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"subscription-service/internal/currency"
+	"subscription-service/internal/model"
+	"subscription-service/internal/notify"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/pkg/period"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BudgetService manages budgets and compares them with the spend computed like the totals.
+type BudgetService struct {
+	subs     *SubService
+	notifier notify.Notifier
+}
+
+func NewBudgetService(subs *SubService, notifier notify.Notifier) *BudgetService {
+	return &BudgetService{subs: subs, notifier: notifier}
+}
+
+// Create adds the budget. Callers limited to their own subscriptions may only budget their own spend.
+func (s *BudgetService) Create(ctx context.Context, budget *model.Budget) error {
+	scoped, owner, err := s.subs.scoped(ctx)
+	if err != nil {
+		return err
+	}
+	if owner != uuid.Nil && budget.UserID == nil {
+		budget.UserID = &owner
+	}
+	if budget.UserID != nil {
+		if err = checkOwner(owner, *budget.UserID); err != nil {
+			return err
+		}
+	}
+
+	prepareBudget(budget)
+	return scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		return repo.CreateBudget(ctx, budget)
+	})
+}
+
+func (s *BudgetService) GetByID(ctx context.Context, id uuid.UUID) (*model.Budget, error) {
+	var budget *model.Budget
+	err := s.subs.read(ctx, func(repo sub.SubscriptionRepository) error {
+		var err error
+		budget, err = repo.GetBudgetByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+func (s *BudgetService) GetAll(ctx context.Context) ([]model.Budget, error) {
+	var budgets []model.Budget
+	err := s.subs.read(ctx, func(repo sub.SubscriptionRepository) error {
+		var err error
+		budgets, err = repo.GetBudgets(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+func (s *BudgetService) Delete(ctx context.Context, id uuid.UUID) error {
+	scoped, _, err := s.subs.scoped(ctx)
+	if err != nil {
+		return err
+	}
+	return scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		return repo.DeleteBudget(ctx, id)
+	})
+}
+
+// Status compares the spend of the month with the budget.
+func (s *BudgetService) Status(ctx context.Context, id uuid.UUID, month string) (*model.BudgetStatus, error) {
+	var status *model.BudgetStatus
+	err := s.subs.read(ctx, func(repo sub.SubscriptionRepository) error {
+		budget, err := repo.GetBudgetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		status, err = s.status(ctx, repo, budget, month)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Evaluate compares every budget of every organization with the spend of the month of now and
+// notifies each threshold reached for the first time in the month. Every threshold is recorded and
+// notified in its own transaction, so it is only recorded as alerted if the notification succeeds
+// and failed ones are retried by the next evaluation, without losing the ones notified before.
+// The higher thresholds of a budget wait for a failed one. It returns the number of alerts sent.
+func (s *BudgetService) Evaluate(ctx context.Context, now time.Time) (int, error) {
+	budgets, err := s.subs.repo.GetBudgets(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	month := period.Format(now)
+	var sent int
+	var errs []error
+	for _, budget := range budgets {
+		repo := s.subs.repo.ForOrganization(budget.OrganizationID)
+		var status *model.BudgetStatus
+		err := repo.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+			var err error
+			status, err = s.status(ctx, repo, &budget, month)
+			return err
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, threshold := range status.Crossed {
+			alert := model.BudgetAlert{
+				BudgetID:       budget.ID,
+				BudgetName:     budget.Name,
+				OrganizationID: budget.OrganizationID,
+				Month:          month,
+				Threshold:      threshold,
+				Amount:         status.Amount,
+				Spent:          status.Spent,
+				Currency:       status.Currency,
+			}
+			notified, err := s.alert(ctx, repo, alert)
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+			if notified {
+				sent++
+			}
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// alert records the alert and notifies it in a transaction, notified is false if it was already recorded.
+// A recorded alert is rolled back rather than committed, as the failed insert aborts a database transaction.
+func (s *BudgetService) alert(ctx context.Context, repo sub.SubscriptionRepository, alert model.BudgetAlert) (bool, error) {
+	err := repo.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		if err := repo.AddBudgetAlert(ctx, &alert); err != nil {
+			return err
+		}
+		return s.notifier.Notify(ctx, alert)
+	})
+	if errors.Is(err, sub.ErrConflict) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// status sums the spend of the budget's subscriptions in the month in the budget currency.
+func (s *BudgetService) status(ctx context.Context, repo sub.SubscriptionRepository, budget *model.Budget, month string) (*model.BudgetStatus, error) {
+	filter := model.TotalFilter{StartDate: month, EndDate: month}
+	if budget.UserID != nil {
+		filter.UserID = *budget.UserID
+	}
+	if budget.Category != nil {
+		filter.Category = *budget.Category
+	}
+
+	totals, err := s.subs.sumMonthlySpend(ctx, repo, filter, budget.Currency, byGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	spent := currency.Round(totals[""])
+	status := &model.BudgetStatus{
+		BudgetID:  budget.ID,
+		Month:     month,
+		Amount:    budget.Amount,
+		Spent:     spent,
+		Remaining: budget.Amount - spent,
+		Percent:   spent * 100 / budget.Amount,
+		Currency:  budget.Currency,
+		Crossed:   []int{},
+	}
+	for _, threshold := range budget.Thresholds {
+		if spent*100 >= budget.Amount*threshold {
+			status.Crossed = append(status.Crossed, threshold)
+		}
+	}
+	return status, nil
+}
+
+// prepareBudget normalizes the currency and category and sorts the thresholds, using the default ones if empty.
+func prepareBudget(budget *model.Budget) {
+	budget.Name = strings.TrimSpace(budget.Name)
+	budget.Currency = strings.ToUpper(budget.Currency)
+	if budget.Currency == "" {
+		budget.Currency = model.DefaultCurrency
+	}
+	if budget.Category != nil {
+		if category := strings.TrimSpace(*budget.Category); category != "" {
+			budget.Category = &category
+		} else {
+			budget.Category = nil
+		}
+	}
+	if len(budget.Thresholds) == 0 {
+		budget.Thresholds = model.DefaultBudgetThresholds
+	}
+	budget.Thresholds = slices.Compact(slices.Sorted(slices.Values(budget.Thresholds)))
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"subscription-service/internal/currency"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub/memory"
+	"subscription-service/pkg/period"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fakeNotifier records the notified thresholds and fails those in fail.
+type fakeNotifier struct {
+	notified []int
+	fail     map[int]bool
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, alert model.BudgetAlert) error {
+	if n.fail[alert.Threshold] {
+		return errors.New("notifier unavailable")
+	}
+	n.notified = append(n.notified, alert.Threshold)
+	return nil
+}
+
+func TestEvaluate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	subs := NewSubService(memory.NewSubMemoryRepository(), currency.NewConverter(nil), Scope{}, OverlapAllow)
+	notifier := &fakeNotifier{}
+	budgets := NewBudgetService(subs, notifier)
+
+	addSub := func(price int) {
+		t.Helper()
+		s := &model.Subscription{ServiceName: "Netflix", Price: price, UserID: uuid.New(), StartDate: period.Format(now)}
+		if err := subs.Create(ctx, s); err != nil {
+			t.Fatalf("Create subscription: %v", err)
+		}
+	}
+	if err := budgets.Create(ctx, &model.Budget{Name: "All", Amount: 1000, Thresholds: []int{80, 50, 100}}); err != nil {
+		t.Fatalf("Create budget: %v", err)
+	}
+	addSub(900)
+
+	steps := []struct {
+		name     string
+		before   func()
+		fail     []int
+		sent     int
+		wantErr  bool
+		notified []int
+	}{
+		{name: "higher thresholds wait for a failed one", fail: []int{50}, sent: 0, wantErr: true},
+		{name: "failed threshold keeps the ones before", fail: []int{80}, sent: 1, wantErr: true, notified: []int{50}},
+		{name: "failed threshold retried", sent: 1, notified: []int{50, 80}},
+		{name: "thresholds alerted once", sent: 0, notified: []int{50, 80}},
+		{name: "higher threshold", before: func() { addSub(200) }, sent: 1, notified: []int{50, 80, 100}},
+	}
+	for _, step := range steps {
+		if step.before != nil {
+			step.before()
+		}
+		notifier.fail = make(map[int]bool)
+		for _, threshold := range step.fail {
+			notifier.fail[threshold] = true
+		}
+
+		sent, err := budgets.Evaluate(ctx, now)
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: err = %v, want error %v", step.name, err, step.wantErr)
+		}
+		if sent != step.sent {
+			t.Errorf("%s: sent %d alerts, want %d", step.name, sent, step.sent)
+		}
+		if !slices.Equal(notifier.notified, step.notified) {
+			t.Errorf("%s: notified %v, want %v", step.name, notifier.notified, step.notified)
+		}
+	}
+}
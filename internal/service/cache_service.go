@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"subscription-service/internal/cache"
+)
+
+// statsSource is implemented by cache.LRU.
+type statsSource interface {
+	Stats() cache.Stats
+}
+
+// CacheService reports the statistics of the repository cache.
+type CacheService struct {
+	cache statsSource
+}
+
+func NewCacheService(cache statsSource) *CacheService {
+	return &CacheService{cache: cache}
+}
+
+func (s *CacheService) Stats(ctx context.Context) (cache.Stats, error) {
+	return s.cache.Stats(), nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownService = errors.New("service not found in catalog")
+	ErrPriceRequired  = errors.New("price is required, service has no default price")
+)
+
+type CatalogService struct {
+	repo sub.SubscriptionRepository
+}
+
+func NewCatalogService(repository sub.SubscriptionRepository) *CatalogService {
+	return &CatalogService{repo: repository}
+}
+
+func (s *CatalogService) Create(ctx context.Context, service *model.Service) error {
+	prepareService(service)
+	return s.repo.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		return repo.CreateService(ctx, service)
+	})
+}
+
+func (s *CatalogService) GetByID(ctx context.Context, id uuid.UUID) (*model.Service, error) {
+	return s.repo.GetServiceByID(ctx, id)
+}
+
+func (s *CatalogService) GetAll(ctx context.Context) ([]model.Service, error) {
+	return s.repo.GetServices(ctx)
+}
+
+func (s *CatalogService) Update(ctx context.Context, id uuid.UUID, service *model.Service) (*model.Service, error) {
+	prepareService(service)
+	var updated *model.Service
+	err := s.repo.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		if err := repo.UpdateService(ctx, id, service); err != nil {
+			return err
+		}
+		var err error
+		updated, err = repo.GetServiceByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *CatalogService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.DeleteService(ctx, id)
+}
+
+// NormalizeAlias makes service names that differ only in case and spacing equal.
+func NormalizeAlias(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// prepareService trims the name and normalizes aliases, adding the name itself as an alias.
+func prepareService(service *model.Service) {
+	service.Name = strings.Join(strings.Fields(service.Name), " ")
+
+	seen := make(map[string]bool)
+	aliases := make([]string, 0, len(service.Aliases)+1)
+	for _, alias := range append([]string{service.Name}, service.Aliases...) {
+		alias = NormalizeAlias(alias)
+		if alias == "" || seen[alias] {
+			continue
+		}
+		seen[alias] = true
+		aliases = append(aliases, alias)
+	}
+	service.Aliases = aliases
+}
+
+// resolveService links the subscription to its catalog entry found by ID or name,
+// registering unknown names in the catalog, and fills the default price and category.
+func resolveService(ctx context.Context, repo sub.SubscriptionRepository, subscription *model.Subscription) error {
+	var service *model.Service
+	var err error
+
+	if subscription.ServiceID != uuid.Nil {
+		service, err = repo.GetServiceByID(ctx, subscription.ServiceID)
+		if err != nil {
+			if errors.Is(err, sub.ErrNotFound) {
+				return ErrUnknownService
+			}
+			return err
+		}
+	} else {
+		service, err = repo.ResolveService(ctx, NormalizeAlias(subscription.ServiceName))
+		if errors.Is(err, sub.ErrNotFound) {
+			service = &model.Service{Name: subscription.ServiceName}
+			prepareService(service)
+			err = repo.CreateService(ctx, service)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	subscription.ServiceID = service.ID
+	subscription.ServiceName = service.Name
+	if subscription.Category == nil {
+		subscription.Category = service.Category
+	}
+	if subscription.Price == 0 {
+		if service.DefaultPrice == nil {
+			return ErrPriceRequired
+		}
+		subscription.Price = *service.DefaultPrice
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/pkg/period"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLifecycleOutOfRange = errors.New("effective month must be within the subscription period")
+	ErrAlreadyPaused       = errors.New("subscription is already paused in that period")
+	ErrNotPaused           = errors.New("subscription is not paused in that month")
+)
+
+// Pause stops charging the subscription from the pause start through its end month,
+// or until resumed if it has none.
+func (s *SubService) Pause(ctx context.Context, id uuid.UUID, pause *model.Pause) (*model.Subscription, error) {
+	from, err := period.Parse(pause.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	until := time.Time{}
+	if pause.EndDate != nil {
+		if until, err = period.Parse(*pause.EndDate); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.lifecycle(ctx, id, model.OperationPause, func(repo sub.SubscriptionRepository, subscription *model.Subscription) error {
+		start, end, err := months(subscription.StartDate, subscription.EndDate)
+		if err != nil {
+			return err
+		}
+		if from.Before(start) || (!until.IsZero() && until.Before(from)) || (!end.IsZero() && (from.After(end) || until.After(end))) {
+			return ErrLifecycleOutOfRange
+		}
+		for _, p := range subscription.Pauses {
+			pStart, pEnd, err := months(p.StartDate, p.EndDate)
+			if err != nil {
+				return err
+			}
+			if (pEnd.IsZero() || !from.After(pEnd)) && (until.IsZero() || !pStart.After(until)) {
+				return ErrAlreadyPaused
+			}
+		}
+
+		pause.SubscriptionID = id
+		return repo.AddPause(ctx, pause)
+	})
+}
+
+// Resume charges the subscription again from the month, ending the pause covering it.
+// A pause starting in that month is removed.
+func (s *SubService) Resume(ctx context.Context, id uuid.UUID, effectiveFrom string) (*model.Subscription, error) {
+	from, err := period.Parse(effectiveFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.lifecycle(ctx, id, model.OperationResume, func(repo sub.SubscriptionRepository, subscription *model.Subscription) error {
+		for _, p := range subscription.Pauses {
+			pStart, pEnd, err := months(p.StartDate, p.EndDate)
+			if err != nil {
+				return err
+			}
+			if pStart.After(from) || (!pEnd.IsZero() && pEnd.Before(from)) {
+				continue
+			}
+			if pStart.Equal(from) {
+				return repo.DeletePause(ctx, id, p.ID)
+			}
+			end := period.Format(from.AddDate(0, -1, 0))
+			p.EndDate = &end
+			return repo.UpdatePause(ctx, &p)
+		}
+		return ErrNotPaused
+	})
+}
+
+// Cancel ends the subscription before the month, which must be after its start
+// and not after its current end.
+func (s *SubService) Cancel(ctx context.Context, id uuid.UUID, effectiveFrom string) (*model.Subscription, error) {
+	from, err := period.Parse(effectiveFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.lifecycle(ctx, id, model.OperationCancel, func(repo sub.SubscriptionRepository, subscription *model.Subscription) error {
+		start, end, err := months(subscription.StartDate, subscription.EndDate)
+		if err != nil {
+			return err
+		}
+		if !from.After(start) || (!end.IsZero() && from.After(end)) {
+			return ErrLifecycleOutOfRange
+		}
+
+		cancelled := *subscription
+		last := period.Format(from.AddDate(0, -1, 0))
+		cancelled.EndDate = &last
+		clipTrialAndPromos(&cancelled, from.AddDate(0, -1, 0))
+		_, err = repo.Update(ctx, id, &cancelled)
+		return err
+	})
+}
+
+// clipTrialAndPromos ends the trial and promos of the subscription by the last month,
+// dropping the promos starting after it.
+func clipTrialAndPromos(subscription *model.Subscription, last time.Time) {
+	end := period.Format(last)
+	if subscription.TrialEnd != nil {
+		if trialEnd, err := period.Parse(*subscription.TrialEnd); err == nil && trialEnd.After(last) {
+			subscription.TrialEnd = &end
+		}
+	}
+
+	promos := make([]model.Promo, 0, len(subscription.Promos))
+	for _, p := range subscription.Promos {
+		pStart, pEnd, err := months(p.StartDate, &p.EndDate)
+		if err != nil || pStart.After(last) {
+			continue
+		}
+		if pEnd.After(last) {
+			p.EndDate = end
+		}
+		promos = append(promos, p)
+	}
+	subscription.Promos = promos
+}
+
+// lifecycle runs the action on the subscription in a transaction, audits the change and publishes it.
+func (s *SubService) lifecycle(ctx context.Context, id uuid.UUID, op string,
+	action func(repo sub.SubscriptionRepository, subscription *model.Subscription) error) (*model.Subscription, error) {
+	scoped, _, err := s.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *model.Subscription
+	err = scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		before, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = action(repo, before); err != nil {
+			return err
+		}
+		if after, err = repo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if err = s.audit(ctx, repo, op, id, before, after); err != nil {
+			return err
+		}
+		return s.publish(ctx, repo, model.EventSubscriptionUpdated, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	setStatus(after, time.Now())
+	return after, nil
+}
+
+// setStatus derives the status of the subscription in the month of now.
+func setStatus(subscription *model.Subscription, now time.Time) {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	start, end, err := months(subscription.StartDate, subscription.EndDate)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case start.After(month):
+		subscription.Status = model.StatusUpcoming
+	case !end.IsZero() && end.Before(month):
+		subscription.Status = model.StatusEnded
+	default:
+		subscription.Status = model.StatusActive
+		if subscription.TrialEnd != nil {
+			if trialEnd, err := period.Parse(*subscription.TrialEnd); err == nil && !trialEnd.Before(month) {
+				subscription.Status = model.StatusTrial
+			}
+		}
+		for _, p := range subscription.Pauses {
+			pStart, pEnd, err := months(p.StartDate, p.EndDate)
+			if err == nil && !pStart.After(month) && (pEnd.IsZero() || !pEnd.Before(month)) {
+				subscription.Status = model.StatusPaused
+			}
+		}
+	}
+}
+
+// months parses a period of months, the end is zero if open.
+func months(start string, end *string) (time.Time, time.Time, error) {
+	from, err := period.Parse(start)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end == nil {
+		return from, time.Time{}, nil
+	}
+	until, err := period.Parse(*end)
+	return from, until, err
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+
+	"github.com/google/uuid"
+)
+
+// OverlapPolicy decides what happens to a subscription overlapping another one
+// of the same user and service, which would be counted twice in the totals.
+type OverlapPolicy string
+
+const (
+	OverlapAllow  OverlapPolicy = "allow"
+	OverlapWarn   OverlapPolicy = "warn"
+	OverlapReject OverlapPolicy = "reject"
+)
+
+// OverlapError rejects a subscription overlapping the subscriptions with the IDs.
+// It matches sub.ErrOverlap, which the database returns without the IDs.
+type OverlapError struct {
+	IDs []uuid.UUID
+}
+
+func (e *OverlapError) Error() string {
+	return fmt.Sprintf("%v: %d subscriptions", sub.ErrOverlap, len(e.IDs))
+}
+
+func (e *OverlapError) Unwrap() error {
+	return sub.ErrOverlap
+}
+
+// checkOverlap applies the overlap policy to the subscription, returning the IDs
+// of the overlapping subscriptions to warn about.
+func (s *SubService) checkOverlap(ctx context.Context, repo sub.SubscriptionRepository, subscription *model.Subscription) ([]uuid.UUID, error) {
+	if s.overlap == OverlapAllow || s.overlap == "" {
+		return nil, nil
+	}
+	ids, err := repo.FindOverlapping(ctx, subscription)
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	if s.overlap == OverlapReject {
+		return nil, &OverlapError{IDs: ids}
+	}
+	return ids, nil
+}
@@ -0,0 +1,492 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"subscription-service/internal/auth"
+	"subscription-service/internal/cache"
+	"subscription-service/internal/model"
+
+	"github.com/google/uuid"
+)
+
+var ErrForbidden = errors.New("operation is not allowed for the caller's roles")
+
+// Operation names an operation guarded by a Policy.
+type Operation string
+
+const (
+	OpCreateSubscription  Operation = "subscription.create"
+	OpGetSubscription     Operation = "subscription.get"
+	OpListSubscriptions   Operation = "subscription.list"
+	OpUpdateSubscription  Operation = "subscription.update"
+	OpDeleteSubscription  Operation = "subscription.delete"
+	OpRestoreSubscription Operation = "subscription.restore"
+	OpPauseSubscription   Operation = "subscription.pause"
+	OpResumeSubscription  Operation = "subscription.resume"
+	OpCancelSubscription  Operation = "subscription.cancel"
+	OpSchedulePrice       Operation = "price.schedule"
+	OpGetPrices           Operation = "price.list"
+	OpGetTotal            Operation = "total.get"
+	OpGetForecast         Operation = "forecast.get"
+	OpGetAuditLog         Operation = "audit.list"
+	OpGetHistory          Operation = "audit.history"
+	OpCreateService       Operation = "service.create"
+	OpGetService          Operation = "service.get"
+	OpListServices        Operation = "service.list"
+	OpUpdateService       Operation = "service.update"
+	OpDeleteService       Operation = "service.delete"
+	OpCreateAPIKey        Operation = "apikey.create"
+	OpListAPIKeys         Operation = "apikey.list"
+	OpRevokeAPIKey        Operation = "apikey.revoke"
+	OpGetCacheStats       Operation = "cache.stats"
+	OpCreateBudget        Operation = "budget.create"
+	OpGetBudget           Operation = "budget.get"
+	OpListBudgets         Operation = "budget.list"
+	OpDeleteBudget        Operation = "budget.delete"
+	OpGetBudgetStatus     Operation = "budget.status"
+	OpCreateWebhook       Operation = "webhook.create"
+	OpGetWebhook          Operation = "webhook.get"
+	OpListWebhooks        Operation = "webhook.list"
+	OpDeleteWebhook       Operation = "webhook.delete"
+	OpListDeadLetters     Operation = "webhook.dead_letters"
+	OpRedeliver           Operation = "webhook.redeliver"
+)
+
+// Policy maps every operation to the roles allowed to perform it.
+// Operations missing from the policy are denied.
+type Policy map[Operation][]string
+
+var (
+	allRoles    = []string{auth.RoleAdmin, auth.RoleUser, auth.RoleAuditor}
+	writerRoles = []string{auth.RoleAdmin, auth.RoleUser}
+	adminRoles  = []string{auth.RoleAdmin}
+)
+
+// DefaultPolicy lets admins do everything, users manage their own subscriptions
+// and auditors read everything. Only admins manage the service catalog and API keys
+// and see the cache statistics.
+var DefaultPolicy = Policy{
+	OpCreateSubscription:  writerRoles,
+	OpGetSubscription:     allRoles,
+	OpListSubscriptions:   allRoles,
+	OpUpdateSubscription:  writerRoles,
+	OpDeleteSubscription:  writerRoles,
+	OpRestoreSubscription: writerRoles,
+	OpPauseSubscription:   writerRoles,
+	OpResumeSubscription:  writerRoles,
+	OpCancelSubscription:  writerRoles,
+	OpSchedulePrice:       writerRoles,
+	OpGetPrices:           allRoles,
+	OpGetTotal:            allRoles,
+	OpGetForecast:         allRoles,
+	OpGetAuditLog:         allRoles,
+	OpGetHistory:          allRoles,
+	OpCreateService:       adminRoles,
+	OpGetService:          allRoles,
+	OpListServices:        allRoles,
+	OpUpdateService:       adminRoles,
+	OpDeleteService:       adminRoles,
+	OpCreateAPIKey:        adminRoles,
+	OpListAPIKeys:         adminRoles,
+	OpRevokeAPIKey:        adminRoles,
+	OpGetCacheStats:       adminRoles,
+	OpCreateBudget:        writerRoles,
+	OpGetBudget:           allRoles,
+	OpListBudgets:         allRoles,
+	OpDeleteBudget:        writerRoles,
+	OpGetBudgetStatus:     allRoles,
+	OpCreateWebhook:       adminRoles,
+	OpGetWebhook:          adminRoles,
+	OpListWebhooks:        adminRoles,
+	OpDeleteWebhook:       adminRoles,
+	OpListDeadLetters:     adminRoles,
+	OpRedeliver:           adminRoles,
+}
+
+// Allows reports whether any of the roles may perform the operation.
+func (p Policy) Allows(op Operation, roles []string) bool {
+	return slices.ContainsFunc(p[op], func(role string) bool {
+		return slices.Contains(roles, role)
+	})
+}
+
+func (p Policy) authorize(ctx context.Context, op Operation) error {
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok || !p.Allows(op, claims.Roles) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// SubscriptionService is the subscription API used by handlers.
+type SubscriptionService interface {
+	Create(ctx context.Context, subscription *model.Subscription) error
+	CreateBatch(ctx context.Context, subscriptions []*model.Subscription) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error)
+	Update(ctx context.Context, id uuid.UUID, subscription *model.Subscription) (*model.Subscription, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Restore(ctx context.Context, id uuid.UUID) (*model.Subscription, error)
+	Pause(ctx context.Context, id uuid.UUID, pause *model.Pause) (*model.Subscription, error)
+	Resume(ctx context.Context, id uuid.UUID, effectiveFrom string) (*model.Subscription, error)
+	Cancel(ctx context.Context, id uuid.UUID, effectiveFrom string) (*model.Subscription, error)
+	GetAll(ctx context.Context, filter model.ListFilter) ([]model.Subscription, error)
+	SchedulePriceChange(ctx context.Context, subID uuid.UUID, change *model.PriceChange) error
+	GetPriceChanges(ctx context.Context, subID uuid.UUID) ([]model.PriceChange, error)
+	GetTotalSum(ctx context.Context, filter model.TotalFilter, target string) (*model.TotalSum, error)
+	GetForecast(ctx context.Context, months int, filter model.TotalFilter, target string) (*model.Forecast, error)
+	GetAuditEntries(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error)
+	GetHistory(ctx context.Context, subID uuid.UUID, filter model.AuditFilter) ([]model.AuditEntry, error)
+}
+
+// CatalogManager is the service catalog API used by handlers.
+type CatalogManager interface {
+	Create(ctx context.Context, service *model.Service) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Service, error)
+	GetAll(ctx context.Context) ([]model.Service, error)
+	Update(ctx context.Context, id uuid.UUID, service *model.Service) (*model.Service, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// APIKeyManager is the API key management used by handlers.
+type APIKeyManager interface {
+	Create(ctx context.Context, req model.APIKeyRequest) (*model.NewAPIKey, error)
+	GetAll(ctx context.Context) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+}
+
+// BudgetManager is the budget API used by handlers.
+type BudgetManager interface {
+	Create(ctx context.Context, budget *model.Budget) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Budget, error)
+	GetAll(ctx context.Context) ([]model.Budget, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Status(ctx context.Context, id uuid.UUID, month string) (*model.BudgetStatus, error)
+}
+
+// WebhookManager is the webhook API used by handlers.
+type WebhookManager interface {
+	Create(ctx context.Context, req model.WebhookRequest) (*model.NewWebhook, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error)
+	GetAll(ctx context.Context) ([]model.Webhook, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeadLetters(ctx context.Context) ([]model.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error)
+}
+
+// CacheMonitor reports cache statistics to handlers.
+type CacheMonitor interface {
+	Stats(ctx context.Context) (cache.Stats, error)
+}
+
+// PolicySubService checks the caller's roles before passing operations to the wrapped service.
+type PolicySubService struct {
+	next   SubscriptionService
+	policy Policy
+}
+
+func NewPolicySubService(next SubscriptionService, policy Policy) *PolicySubService {
+	return &PolicySubService{next: next, policy: policy}
+}
+
+func (s *PolicySubService) Create(ctx context.Context, subscription *model.Subscription) error {
+	if err := s.policy.authorize(ctx, OpCreateSubscription); err != nil {
+		return err
+	}
+	return s.next.Create(ctx, subscription)
+}
+
+func (s *PolicySubService) CreateBatch(ctx context.Context, subscriptions []*model.Subscription) error {
+	if err := s.policy.authorize(ctx, OpCreateSubscription); err != nil {
+		return err
+	}
+	return s.next.CreateBatch(ctx, subscriptions)
+}
+
+func (s *PolicySubService) GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	if err := s.policy.authorize(ctx, OpGetSubscription); err != nil {
+		return nil, err
+	}
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *PolicySubService) Update(ctx context.Context, id uuid.UUID, subscription *model.Subscription) (*model.Subscription, error) {
+	if err := s.policy.authorize(ctx, OpUpdateSubscription); err != nil {
+		return nil, err
+	}
+	return s.next.Update(ctx, id, subscription)
+}
+
+func (s *PolicySubService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.policy.authorize(ctx, OpDeleteSubscription); err != nil {
+		return err
+	}
+	return s.next.Delete(ctx, id)
+}
+
+func (s *PolicySubService) Restore(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	if err := s.policy.authorize(ctx, OpRestoreSubscription); err != nil {
+		return nil, err
+	}
+	return s.next.Restore(ctx, id)
+}
+
+func (s *PolicySubService) Pause(ctx context.Context, id uuid.UUID, pause *model.Pause) (*model.Subscription, error) {
+	if err := s.policy.authorize(ctx, OpPauseSubscription); err != nil {
+		return nil, err
+	}
+	return s.next.Pause(ctx, id, pause)
+}
+
+func (s *PolicySubService) Resume(ctx context.Context, id uuid.UUID, effectiveFrom string) (*model.Subscription, error) {
+	if err := s.policy.authorize(ctx, OpResumeSubscription); err != nil {
+		return nil, err
+	}
+	return s.next.Resume(ctx, id, effectiveFrom)
+}
+
+func (s *PolicySubService) Cancel(ctx context.Context, id uuid.UUID, effectiveFrom string) (*model.Subscription, error) {
+	if err := s.policy.authorize(ctx, OpCancelSubscription); err != nil {
+		return nil, err
+	}
+	return s.next.Cancel(ctx, id, effectiveFrom)
+}
+
+func (s *PolicySubService) GetAll(ctx context.Context, filter model.ListFilter) ([]model.Subscription, error) {
+	if err := s.policy.authorize(ctx, OpListSubscriptions); err != nil {
+		return nil, err
+	}
+	return s.next.GetAll(ctx, filter)
+}
+
+func (s *PolicySubService) SchedulePriceChange(ctx context.Context, subID uuid.UUID, change *model.PriceChange) error {
+	if err := s.policy.authorize(ctx, OpSchedulePrice); err != nil {
+		return err
+	}
+	return s.next.SchedulePriceChange(ctx, subID, change)
+}
+
+func (s *PolicySubService) GetPriceChanges(ctx context.Context, subID uuid.UUID) ([]model.PriceChange, error) {
+	if err := s.policy.authorize(ctx, OpGetPrices); err != nil {
+		return nil, err
+	}
+	return s.next.GetPriceChanges(ctx, subID)
+}
+
+func (s *PolicySubService) GetTotalSum(ctx context.Context, filter model.TotalFilter, target string) (*model.TotalSum, error) {
+	if err := s.policy.authorize(ctx, OpGetTotal); err != nil {
+		return nil, err
+	}
+	return s.next.GetTotalSum(ctx, filter, target)
+}
+
+func (s *PolicySubService) GetForecast(ctx context.Context, months int, filter model.TotalFilter, target string) (*model.Forecast, error) {
+	if err := s.policy.authorize(ctx, OpGetForecast); err != nil {
+		return nil, err
+	}
+	return s.next.GetForecast(ctx, months, filter, target)
+}
+
+func (s *PolicySubService) GetAuditEntries(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	if err := s.policy.authorize(ctx, OpGetAuditLog); err != nil {
+		return nil, err
+	}
+	return s.next.GetAuditEntries(ctx, filter)
+}
+
+func (s *PolicySubService) GetHistory(ctx context.Context, subID uuid.UUID, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	if err := s.policy.authorize(ctx, OpGetHistory); err != nil {
+		return nil, err
+	}
+	return s.next.GetHistory(ctx, subID, filter)
+}
+
+// PolicyCatalogService checks the caller's roles before passing operations to the wrapped catalog.
+type PolicyCatalogService struct {
+	next   CatalogManager
+	policy Policy
+}
+
+func NewPolicyCatalogService(next CatalogManager, policy Policy) *PolicyCatalogService {
+	return &PolicyCatalogService{next: next, policy: policy}
+}
+
+func (s *PolicyCatalogService) Create(ctx context.Context, service *model.Service) error {
+	if err := s.policy.authorize(ctx, OpCreateService); err != nil {
+		return err
+	}
+	return s.next.Create(ctx, service)
+}
+
+func (s *PolicyCatalogService) GetByID(ctx context.Context, id uuid.UUID) (*model.Service, error) {
+	if err := s.policy.authorize(ctx, OpGetService); err != nil {
+		return nil, err
+	}
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *PolicyCatalogService) GetAll(ctx context.Context) ([]model.Service, error) {
+	if err := s.policy.authorize(ctx, OpListServices); err != nil {
+		return nil, err
+	}
+	return s.next.GetAll(ctx)
+}
+
+func (s *PolicyCatalogService) Update(ctx context.Context, id uuid.UUID, service *model.Service) (*model.Service, error) {
+	if err := s.policy.authorize(ctx, OpUpdateService); err != nil {
+		return nil, err
+	}
+	return s.next.Update(ctx, id, service)
+}
+
+func (s *PolicyCatalogService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.policy.authorize(ctx, OpDeleteService); err != nil {
+		return err
+	}
+	return s.next.Delete(ctx, id)
+}
+
+// PolicyAPIKeyService checks the caller's roles before passing operations to the wrapped key service.
+type PolicyAPIKeyService struct {
+	next   APIKeyManager
+	policy Policy
+}
+
+func NewPolicyAPIKeyService(next APIKeyManager, policy Policy) *PolicyAPIKeyService {
+	return &PolicyAPIKeyService{next: next, policy: policy}
+}
+
+func (s *PolicyAPIKeyService) Create(ctx context.Context, req model.APIKeyRequest) (*model.NewAPIKey, error) {
+	if err := s.policy.authorize(ctx, OpCreateAPIKey); err != nil {
+		return nil, err
+	}
+	return s.next.Create(ctx, req)
+}
+
+func (s *PolicyAPIKeyService) GetAll(ctx context.Context) ([]model.APIKey, error) {
+	if err := s.policy.authorize(ctx, OpListAPIKeys); err != nil {
+		return nil, err
+	}
+	return s.next.GetAll(ctx)
+}
+
+func (s *PolicyAPIKeyService) Revoke(ctx context.Context, id uuid.UUID) error {
+	if err := s.policy.authorize(ctx, OpRevokeAPIKey); err != nil {
+		return err
+	}
+	return s.next.Revoke(ctx, id)
+}
+
+// PolicyBudgetService checks the caller's roles before passing operations to the wrapped budgets.
+type PolicyBudgetService struct {
+	next   BudgetManager
+	policy Policy
+}
+
+func NewPolicyBudgetService(next BudgetManager, policy Policy) *PolicyBudgetService {
+	return &PolicyBudgetService{next: next, policy: policy}
+}
+
+func (s *PolicyBudgetService) Create(ctx context.Context, budget *model.Budget) error {
+	if err := s.policy.authorize(ctx, OpCreateBudget); err != nil {
+		return err
+	}
+	return s.next.Create(ctx, budget)
+}
+
+func (s *PolicyBudgetService) GetByID(ctx context.Context, id uuid.UUID) (*model.Budget, error) {
+	if err := s.policy.authorize(ctx, OpGetBudget); err != nil {
+		return nil, err
+	}
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *PolicyBudgetService) GetAll(ctx context.Context) ([]model.Budget, error) {
+	if err := s.policy.authorize(ctx, OpListBudgets); err != nil {
+		return nil, err
+	}
+	return s.next.GetAll(ctx)
+}
+
+func (s *PolicyBudgetService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.policy.authorize(ctx, OpDeleteBudget); err != nil {
+		return err
+	}
+	return s.next.Delete(ctx, id)
+}
+
+func (s *PolicyBudgetService) Status(ctx context.Context, id uuid.UUID, month string) (*model.BudgetStatus, error) {
+	if err := s.policy.authorize(ctx, OpGetBudgetStatus); err != nil {
+		return nil, err
+	}
+	return s.next.Status(ctx, id, month)
+}
+
+type PolicyWebhookService struct {
+	next   WebhookManager
+	policy Policy
+}
+
+func NewPolicyWebhookService(next WebhookManager, policy Policy) *PolicyWebhookService {
+	return &PolicyWebhookService{next: next, policy: policy}
+}
+
+func (s *PolicyWebhookService) Create(ctx context.Context, req model.WebhookRequest) (*model.NewWebhook, error) {
+	if err := s.policy.authorize(ctx, OpCreateWebhook); err != nil {
+		return nil, err
+	}
+	return s.next.Create(ctx, req)
+}
+
+func (s *PolicyWebhookService) GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	if err := s.policy.authorize(ctx, OpGetWebhook); err != nil {
+		return nil, err
+	}
+	return s.next.GetByID(ctx, id)
+}
+
+func (s *PolicyWebhookService) GetAll(ctx context.Context) ([]model.Webhook, error) {
+	if err := s.policy.authorize(ctx, OpListWebhooks); err != nil {
+		return nil, err
+	}
+	return s.next.GetAll(ctx)
+}
+
+func (s *PolicyWebhookService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.policy.authorize(ctx, OpDeleteWebhook); err != nil {
+		return err
+	}
+	return s.next.Delete(ctx, id)
+}
+
+func (s *PolicyWebhookService) DeadLetters(ctx context.Context) ([]model.WebhookDelivery, error) {
+	if err := s.policy.authorize(ctx, OpListDeadLetters); err != nil {
+		return nil, err
+	}
+	return s.next.DeadLetters(ctx)
+}
+
+func (s *PolicyWebhookService) Redeliver(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	if err := s.policy.authorize(ctx, OpRedeliver); err != nil {
+		return nil, err
+	}
+	return s.next.Redeliver(ctx, id)
+}
+
+// PolicyCacheService checks the caller's roles before reporting cache statistics.
+type PolicyCacheService struct {
+	next   CacheMonitor
+	policy Policy
+}
+
+func NewPolicyCacheService(next CacheMonitor, policy Policy) *PolicyCacheService {
+	return &PolicyCacheService{next: next, policy: policy}
+}
+
+func (s *PolicyCacheService) Stats(ctx context.Context) (cache.Stats, error) {
+	if err := s.policy.authorize(ctx, OpGetCacheStats); err != nil {
+		return cache.Stats{}, err
+	}
+	return s.next.Stats(ctx)
+}
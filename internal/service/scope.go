@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"subscription-service/internal/auth"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/internal/reqctx"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNoIdentity     = errors.New("caller has no user identity")
+	ErrForeignUserID  = errors.New("subscriptions of other users can't be managed")
+	ErrNoOrganization = errors.New("request has no organization")
+	// ErrForeignOrganization rejects API keys requested for another organization than the caller's.
+	ErrForeignOrganization = errors.New("API keys of other organizations can't be created")
+)
+
+// Scope configures which subscriptions callers see.
+type Scope struct {
+	// UserIsolation limits callers other than admins and auditors to their own subscriptions.
+	UserIsolation bool
+	// MultiTenant limits every request to the subscriptions of its organization.
+	MultiTenant bool
+	// RowLevelSecurity runs tenant scoped reads in a transaction,
+	// so that the repository can set the organization for the database policies.
+	RowLevelSecurity bool
+}
+
+// scoped returns the repository the caller may change data in and the user the caller is limited to.
+// With user isolation, callers other than admins only change their own subscriptions, so foreign
+// subscriptions are reported as not found. Subscriptions of other organizations are never visible
+// in multi-tenant mode.
+func (s *SubService) scoped(ctx context.Context) (sub.SubscriptionRepository, uuid.UUID, error) {
+	return s.scopedFor(ctx, false)
+}
+
+// scopedFor returns the repository of scoped, reads also give auditors the subscriptions of all users.
+func (s *SubService) scopedFor(ctx context.Context, read bool) (sub.SubscriptionRepository, uuid.UUID, error) {
+	repo := s.repo
+	if s.scope.MultiTenant {
+		orgID, ok := reqctx.Organization(ctx)
+		if !ok {
+			return nil, uuid.Nil, ErrNoOrganization
+		}
+		repo = repo.ForOrganization(orgID)
+	}
+
+	if !s.scope.UserIsolation {
+		return repo, uuid.Nil, nil
+	}
+
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, uuid.Nil, ErrNoIdentity
+	}
+	if claims.HasRole(auth.RoleAdmin) || (read && claims.HasRole(auth.RoleAuditor)) {
+		return repo, uuid.Nil, nil
+	}
+
+	userID, err := claims.User()
+	if err != nil || userID == uuid.Nil {
+		return nil, uuid.Nil, ErrNoIdentity
+	}
+	return repo.ForUser(userID), userID, nil
+}
+
+// read runs fn with the repository visible to the caller. With row-level security,
+// tenant scoped reads run in a transaction to make the organization known to the database.
+func (s *SubService) read(ctx context.Context, fn func(repo sub.SubscriptionRepository) error) error {
+	scoped, _, err := s.scopedFor(ctx, true)
+	if err != nil {
+		return err
+	}
+	if s.scope.MultiTenant && s.scope.RowLevelSecurity {
+		return scoped.WithTx(ctx, fn)
+	}
+	return fn(scoped)
+}
+
+// checkOwner rejects subscriptions assigned to another user than the caller is limited to.
+func checkOwner(owner, userID uuid.UUID) error {
+	if owner != uuid.Nil && owner != userID {
+		return ErrForeignUserID
+	}
+	return nil
+}
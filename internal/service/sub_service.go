@@ -1,44 +1,481 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"subscription-service/internal/currency"
 	"subscription-service/internal/model"
 	"subscription-service/internal/repository/sub"
+	"subscription-service/pkg/period"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var ErrPriceChangeOutOfRange = errors.New("price change must be within the subscription period")
+
 type SubService struct {
-	repo sub.SubscriptionRepository
+	repo      sub.SubscriptionRepository
+	converter *currency.Converter
+	scope     Scope
+	overlap   OverlapPolicy
+}
+
+func NewSubService(repository sub.SubscriptionRepository, converter *currency.Converter, scope Scope, overlap OverlapPolicy) *SubService {
+	return &SubService{repo: repository, converter: converter, scope: scope, overlap: overlap}
+}
+
+func (s *SubService) Create(ctx context.Context, subscription *model.Subscription) error {
+	scoped, owner, err := s.scoped(ctx)
+	if err != nil {
+		return err
+	}
+	if err = checkOwner(owner, subscription.UserID); err != nil {
+		return err
+	}
+
+	normalize(subscription)
+	var overlaps []uuid.UUID
+	err = scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		if err := resolveService(ctx, repo, subscription); err != nil {
+			return err
+		}
+		var err error
+		if overlaps, err = s.checkOverlap(ctx, repo, subscription); err != nil {
+			return err
+		}
+		if err = repo.Create(ctx, subscription); err != nil {
+			return err
+		}
+		if err = s.audit(ctx, repo, model.OperationCreate, subscription.ID, nil, subscription); err != nil {
+			return err
+		}
+		return s.publish(ctx, repo, model.EventSubscriptionCreated, subscription)
+	})
+	if err != nil {
+		return err
+	}
+	subscription.OverlapsWith = overlaps
+	setStatus(subscription, time.Now())
+	return nil
+}
+
+// CreateBatch creates the subscriptions in a single transaction, none are created if one fails.
+func (s *SubService) CreateBatch(ctx context.Context, subscriptions []*model.Subscription) error {
+	scoped, owner, err := s.scoped(ctx)
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		if err = checkOwner(owner, subscription.UserID); err != nil {
+			return err
+		}
+		normalize(subscription)
+	}
+
+	// Subscriptions of the batch may also overlap each other, as the earlier ones are already created.
+	overlaps := make([][]uuid.UUID, len(subscriptions))
+	err = scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		for i, subscription := range subscriptions {
+			if err := resolveService(ctx, repo, subscription); err != nil {
+				return err
+			}
+			var err error
+			if overlaps[i], err = s.checkOverlap(ctx, repo, subscription); err != nil {
+				return err
+			}
+			if err = repo.Create(ctx, subscription); err != nil {
+				return err
+			}
+			if err = s.audit(ctx, repo, model.OperationCreate, subscription.ID, nil, subscription); err != nil {
+				return err
+			}
+			if err = s.publish(ctx, repo, model.EventSubscriptionCreated, subscription); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, subscription := range subscriptions {
+		subscription.OverlapsWith = overlaps[i]
+		setStatus(subscription, time.Now())
+	}
+	return nil
+}
+
+func (s *SubService) GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	var subscription *model.Subscription
+	err := s.read(ctx, func(repo sub.SubscriptionRepository) error {
+		var err error
+		subscription, err = repo.GetByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	setStatus(subscription, time.Now())
+	return subscription, nil
+}
+
+func (s *SubService) Update(ctx context.Context, id uuid.UUID, subscription *model.Subscription) (*model.Subscription, error) {
+	scoped, owner, err := s.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkOwner(owner, subscription.UserID); err != nil {
+		return nil, err
+	}
+
+	normalize(subscription)
+	subscription.ID = id
+	var updated *model.Subscription
+	var overlaps []uuid.UUID
+	err = scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		before, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = resolveService(ctx, repo, subscription); err != nil {
+			return err
+		}
+		if overlaps, err = s.checkOverlap(ctx, repo, subscription); err != nil {
+			return err
+		}
+		if updated, err = repo.Update(ctx, id, subscription); err != nil {
+			return err
+		}
+		if err = s.audit(ctx, repo, model.OperationUpdate, id, before, updated); err != nil {
+			return err
+		}
+		return s.publish(ctx, repo, model.EventSubscriptionUpdated, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	updated.OverlapsWith = overlaps
+	setStatus(updated, time.Now())
+	return updated, nil
+}
+
+func (s *SubService) Delete(ctx context.Context, id uuid.UUID) error {
+	scoped, _, err := s.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	return scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		before, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		if err = s.audit(ctx, repo, model.OperationDelete, id, before, nil); err != nil {
+			return err
+		}
+		return s.publish(ctx, repo, model.EventSubscriptionDeleted, before)
+	})
+}
+
+func (s *SubService) Restore(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	scoped, _, err := s.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var restored *model.Subscription
+	var overlaps []uuid.UUID
+	err = scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		deleted, err := repo.GetDeletedByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		// Subscriptions created since the deletion may overlap the restored one.
+		if overlaps, err = s.checkOverlap(ctx, repo, deleted); err != nil {
+			return err
+		}
+		if err = repo.Restore(ctx, id); err != nil {
+			return err
+		}
+		if restored, err = repo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if err = s.audit(ctx, repo, model.OperationRestore, id, nil, restored); err != nil {
+			return err
+		}
+		return s.publish(ctx, repo, model.EventSubscriptionUpdated, restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	restored.OverlapsWith = overlaps
+	setStatus(restored, time.Now())
+	return restored, nil
+}
+
+// PurgeDeleted permanently removes subscriptions deleted longer than retention ago.
+func (s *SubService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.Purge(ctx, time.Now().Add(-retention))
+}
+
+func (s *SubService) GetAll(ctx context.Context, filter model.ListFilter) ([]model.Subscription, error) {
+	var subs []model.Subscription
+	err := s.read(ctx, func(repo sub.SubscriptionRepository) error {
+		var err error
+		subs, err = repo.GetAll(ctx, filter)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range subs {
+		setStatus(&subs[i], now)
+	}
+	return subs, nil
 }
 
-func NewSubService(repository sub.SubscriptionRepository) *SubService {
-	return &SubService{repo: repository}
+// SchedulePriceChange sets a new price from the given month, replacing a change already scheduled for it.
+func (s *SubService) SchedulePriceChange(ctx context.Context, subID uuid.UUID, change *model.PriceChange) error {
+	scoped, _, err := s.scoped(ctx)
+	if err != nil {
+		return err
+	}
+
+	from, err := period.Parse(change.EffectiveFrom)
+	if err != nil {
+		return err
+	}
+
+	return scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		subscription, err := repo.GetByID(ctx, subID)
+		if err != nil {
+			return err
+		}
+
+		start, err := period.Parse(subscription.StartDate)
+		if err != nil {
+			return err
+		}
+		if !from.After(start) {
+			return ErrPriceChangeOutOfRange
+		}
+		if subscription.EndDate != nil {
+			end, err := period.Parse(*subscription.EndDate)
+			if err != nil {
+				return err
+			}
+			if from.After(end) {
+				return ErrPriceChangeOutOfRange
+			}
+		}
+
+		change.SubscriptionID = subID
+		return repo.AddPriceChange(ctx, change)
+	})
 }
 
-func (s *SubService) Create(sub *model.Subscription) error {
-	return s.repo.Create(sub)
+func (s *SubService) GetPriceChanges(ctx context.Context, subID uuid.UUID) ([]model.PriceChange, error) {
+	var changes []model.PriceChange
+	err := s.read(ctx, func(repo sub.SubscriptionRepository) error {
+		if _, err := repo.GetByID(ctx, subID); err != nil {
+			return err
+		}
+		var err error
+		changes, err = repo.GetPriceChanges(ctx, subID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
 }
 
-func (s *SubService) GetByID(id uuid.UUID) (*model.Subscription, error) {
-	return s.repo.GetByID(id)
+// GetTotalSum sums monthly charges of the period converted to the target currency
+// at the rate of each charge month.
+func (s *SubService) GetTotalSum(ctx context.Context, filter model.TotalFilter, target string) (*model.TotalSum, error) {
+	if target == "" {
+		target = model.DefaultCurrency
+	}
+
+	filter, found, err := s.resolveTotalFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	sum := &model.TotalSum{Currency: target}
+	if !found {
+		return sum, nil
+	}
+
+	groupBy := filter.GroupBy
+	err = s.read(ctx, func(repo sub.SubscriptionRepository) error {
+		filter.GroupBy = ""
+		totals, err := s.sumMonthlySpend(ctx, repo, filter, target, byGroup)
+		if err != nil {
+			return err
+		}
+		sum.TotalSum = currency.Round(totals[""])
+
+		if groupBy == "" {
+			return nil
+		}
+		filter.GroupBy = groupBy
+		if totals, err = s.sumMonthlySpend(ctx, repo, filter, target, byGroup); err != nil {
+			return err
+		}
+		sum.Groups = make([]model.GroupSum, 0, len(totals))
+		for key, total := range totals {
+			sum.Groups = append(sum.Groups, model.GroupSum{Key: key, TotalSum: currency.Round(total)})
+		}
+		sort.Slice(sum.Groups, func(i, j int) bool { return sum.Groups[i].Key < sum.Groups[j].Key })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sum, nil
 }
 
-func (s *SubService) Update(id uuid.UUID, sub *model.Subscription) (*model.Subscription, error) {
-	err := s.repo.Update(id, sub)
+// GetForecast projects the spend of the given number of months from the current one on, charging
+// the subscriptions that aren't deleted the same way as GetTotalSum. Open-ended subscriptions keep
+// being charged in the months due by their billing interval, scheduled price changes, trials, promos
+// and pauses apply in their months.
+func (s *SubService) GetForecast(ctx context.Context, months int, filter model.TotalFilter, target string) (*model.Forecast, error) {
+	if target == "" {
+		target = model.DefaultCurrency
+	}
+
+	now := time.Now()
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	forecast := &model.Forecast{Currency: target, Months: make([]model.MonthSum, months)}
+	for i := range forecast.Months {
+		forecast.Months[i].Month = period.Format(first.AddDate(0, i, 0))
+	}
+	if months == 0 {
+		return forecast, nil
+	}
+
+	filter.StartDate, filter.EndDate = forecast.Months[0].Month, forecast.Months[months-1].Month
+	filter.IncludeDeleted, filter.GroupBy = false, ""
+	filter, found, err := s.resolveTotalFilter(ctx, filter)
+	if err != nil || !found {
+		return forecast, err
+	}
+
+	err = s.read(ctx, func(repo sub.SubscriptionRepository) error {
+		totals, err := s.sumMonthlySpend(ctx, repo, filter, target, byMonth)
+		if err != nil {
+			return err
+		}
+		for i := range forecast.Months {
+			forecast.Months[i].TotalSum = currency.Round(totals[forecast.Months[i].Month])
+			forecast.TotalSum += forecast.Months[i].TotalSum
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return s.repo.GetByID(id)
+
+	return forecast, nil
 }
 
-func (s *SubService) Delete(id uuid.UUID) error {
-	return s.repo.Delete(id)
+// resolveTotalFilter replaces the service name of the filter by the ID of the service it refers to
+// and normalizes the tags, found is false if no service has the name.
+func (s *SubService) resolveTotalFilter(ctx context.Context, filter model.TotalFilter) (model.TotalFilter, bool, error) {
+	if filter.ServiceName != "" {
+		service, err := s.repo.ResolveService(ctx, NormalizeAlias(filter.ServiceName))
+		if err != nil {
+			if errors.Is(err, sub.ErrNotFound) {
+				return filter, false, nil
+			}
+			return filter, false, err
+		}
+		filter.ServiceID, filter.ServiceName = service.ID, ""
+	}
+
+	filter.Tags = NormalizeTags(filter.Tags)
+	return filter, true, nil
 }
 
-func (s *SubService) GetAll() ([]model.Subscription, error) {
-	return s.repo.GetAll()
+func byGroup(m model.MonthlySpend) string { return m.Group }
+
+func byMonth(m model.MonthlySpend) string { return m.Month }
+
+// sumMonthlySpend converts the monthly spend to the target currency and sums it per key.
+func (s *SubService) sumMonthlySpend(ctx context.Context, repo sub.SubscriptionRepository, filter model.TotalFilter, target string,
+	key func(model.MonthlySpend) string) (map[string]float64, error) {
+	spend, err := repo.GetMonthlySpend(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]float64)
+	for _, m := range spend {
+		month, err := period.Parse(m.Month)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := s.converter.Convert(float64(m.Amount), m.Currency, target, month)
+		if err != nil {
+			return nil, err
+		}
+		totals[key(m)] += amount
+	}
+	return totals, nil
 }
 
-func (s *SubService) GetTotalSum(start, end string, userID uuid.UUID, name string) (int, error) {
-	return s.repo.GetTotalSum(start, end, userID, name)
+func (s *SubService) audit(ctx context.Context, repo sub.SubscriptionRepository, op string, id uuid.UUID, before, after *model.Subscription) error {
+	entry, err := newAuditEntry(ctx, op, id, before, after)
+	if err != nil {
+		return err
+	}
+	return repo.AddAuditEntry(ctx, entry)
+}
+
+func normalize(sub *model.Subscription) {
+	sub.Currency = strings.ToUpper(sub.Currency)
+	if sub.Currency == "" {
+		sub.Currency = model.DefaultCurrency
+	}
+	if sub.BillingInterval == 0 {
+		sub.BillingInterval = 1
+	}
+	if sub.EndDate != nil && *sub.EndDate == "" {
+		sub.EndDate = nil
+	}
+	if sub.TrialEnd != nil && *sub.TrialEnd == "" {
+		sub.TrialEnd = nil
+	}
+	if sub.Category != nil {
+		if category := strings.TrimSpace(*sub.Category); category != "" {
+			sub.Category = &category
+		} else {
+			sub.Category = nil
+		}
+	}
+	sub.Tags = NormalizeTags(sub.Tags)
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty ones and duplicates.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
 }
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"subscription-service/internal/auth"
+	"subscription-service/internal/currency"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/internal/repository/sub/memory"
+	"subscription-service/pkg/period"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRestoreChecksOverlap(t *testing.T) {
+	ctx := context.Background()
+	user := uuid.New()
+	newSub := func() *model.Subscription {
+		return &model.Subscription{ServiceName: "Netflix", Price: 100, UserID: user, StartDate: "01-2025"}
+	}
+
+	repo := memory.NewSubMemoryRepository()
+	svc := NewSubService(repo, currency.NewConverter(nil), Scope{}, OverlapReject)
+	deleted := newSub()
+	if err := svc.Create(ctx, deleted); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := svc.Delete(ctx, deleted.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	replacement := newSub()
+	if err := svc.Create(ctx, replacement); err != nil {
+		t.Fatalf("Create replacement: %v", err)
+	}
+
+	_, err := svc.Restore(ctx, deleted.ID)
+	var overlap *OverlapError
+	if !errors.As(err, &overlap) || len(overlap.IDs) != 1 || overlap.IDs[0] != replacement.ID {
+		t.Fatalf("Restore: err = %v, want overlap with %s", err, replacement.ID)
+	}
+	if _, err = repo.GetDeletedByID(ctx, deleted.ID); err != nil {
+		t.Errorf("rejected restore left the subscription restored: %v", err)
+	}
+
+	warn := NewSubService(repo, currency.NewConverter(nil), Scope{}, OverlapWarn)
+	restored, err := warn.Restore(ctx, deleted.ID)
+	if err != nil {
+		t.Fatalf("Restore with warnings: %v", err)
+	}
+	if len(restored.OverlapsWith) != 1 || restored.OverlapsWith[0] != replacement.ID {
+		t.Errorf("overlaps = %v, want %s", restored.OverlapsWith, replacement.ID)
+	}
+	if _, err = warn.Restore(ctx, deleted.ID); !errors.Is(err, sub.ErrNotFound) {
+		t.Errorf("Restore of an active subscription: err = %v, want %v", err, sub.ErrNotFound)
+	}
+}
+
+func TestAuditorOnlyReadsOtherUsers(t *testing.T) {
+	owner, auditor := uuid.New(), uuid.New()
+	as := func(userID uuid.UUID, roles ...string) context.Context {
+		return auth.WithClaims(context.Background(), &auth.Claims{UserID: userID.String(), Roles: roles})
+	}
+
+	svc := NewSubService(memory.NewSubMemoryRepository(), currency.NewConverter(nil), Scope{UserIsolation: true}, OverlapAllow)
+	subscription := &model.Subscription{ServiceName: "Netflix", Price: 100, UserID: owner, StartDate: "01-2025"}
+	if err := svc.Create(as(owner, auth.RoleUser), subscription); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// A token with both roles must not write as an auditor either.
+	for _, roles := range [][]string{{auth.RoleAuditor}, {auth.RoleUser, auth.RoleAuditor}} {
+		ctx := as(auditor, roles...)
+		if _, err := svc.GetByID(ctx, subscription.ID); err != nil {
+			t.Errorf("%v GetByID: %v", roles, err)
+		}
+		update := &model.Subscription{ServiceName: "Netflix", Price: 1, UserID: auditor, StartDate: "01-2025"}
+		if _, err := svc.Update(ctx, subscription.ID, update); !errors.Is(err, sub.ErrNotFound) {
+			t.Errorf("%v Update: err = %v, want %v", roles, err, sub.ErrNotFound)
+		}
+		if err := svc.Delete(ctx, subscription.ID); !errors.Is(err, sub.ErrNotFound) {
+			t.Errorf("%v Delete: err = %v, want %v", roles, err, sub.ErrNotFound)
+		}
+		if _, err := svc.Cancel(ctx, subscription.ID, "03-2025"); !errors.Is(err, sub.ErrNotFound) {
+			t.Errorf("%v Cancel: err = %v, want %v", roles, err, sub.ErrNotFound)
+		}
+	}
+
+	stored, err := svc.GetByID(as(owner, auth.RoleUser), subscription.ID)
+	if err != nil || stored.Price != 100 || stored.EndDate != nil {
+		t.Errorf("subscription changed by the auditor: %+v, %v", stored, err)
+	}
+}
+
+func TestForecastChargesDueMonths(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	svc := NewSubService(memory.NewSubMemoryRepository(), currency.NewConverter(nil), Scope{}, OverlapAllow)
+	subs := []*model.Subscription{
+		{ServiceName: "Monthly", Price: 100, StartDate: period.Format(thisMonth)},
+		{ServiceName: "Quarterly", Price: 1000, BillingInterval: 3, StartDate: period.Format(thisMonth)},
+		{ServiceName: "Yearly", Price: 10000, BillingInterval: 12, StartDate: period.Format(thisMonth.AddDate(0, -1, 0))},
+	}
+	for _, s := range subs {
+		s.UserID = uuid.New()
+		if err := svc.Create(ctx, s); err != nil {
+			t.Fatalf("Create %s: %v", s.ServiceName, err)
+		}
+	}
+
+	forecast, err := svc.GetForecast(ctx, 12, model.TotalFilter{}, "")
+	if err != nil {
+		t.Fatalf("GetForecast: %v", err)
+	}
+	want := []int{1100, 100, 100, 1100, 100, 100, 1100, 100, 100, 1100, 100, 10100}
+	for i, month := range forecast.Months {
+		if month.TotalSum != want[i] {
+			t.Errorf("%s: total = %d, want %d", month.Month, month.TotalSum, want[i])
+		}
+	}
+	if forecast.TotalSum != 15200 {
+		t.Errorf("total = %d, want %d", forecast.TotalSum, 15200)
+	}
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub"
+	"subscription-service/internal/webhook"
+	"subscription-service/pkg/period"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookSecretBytes  = 32
+	// dispatchBatch is the number of deliveries claimed by one dispatch.
+	dispatchBatch = 20
+	// maxLastErrorLength truncates the errors of failed attempts stored with the delivery.
+	maxLastErrorLength = 512
+)
+
+var (
+	ErrDeliveryPending   = errors.New("delivery is still pending")
+	ErrDeliveryDelivered = errors.New("delivery was already delivered")
+)
+
+// DeliveryPolicy configures how deliveries are sent and retried. A failed attempt is retried after
+// Backoff doubled for every previous attempt, the delivery is dead after MaxAttempts. Webhooks
+// must resolve to public addresses unless AllowPrivate is set.
+type DeliveryPolicy struct {
+	MaxAttempts  int
+	Backoff      time.Duration
+	Timeout      time.Duration
+	AllowPrivate bool
+}
+
+// WebhookService manages webhooks and delivers the subscription events queued by SubService.
+type WebhookService struct {
+	subs   *SubService
+	sender *webhook.Sender
+	policy DeliveryPolicy
+}
+
+func NewWebhookService(subs *SubService, policy DeliveryPolicy) *WebhookService {
+	return &WebhookService{subs: subs, sender: webhook.NewSender(policy.Timeout, policy.AllowPrivate), policy: policy}
+}
+
+// Create adds the webhook to the organization of the caller and returns its secret,
+// which can't be retrieved later. A secret is generated if the request has none.
+// URLs not resolving to public addresses are rejected with webhook.ErrPrivateTarget.
+func (s *WebhookService) Create(ctx context.Context, req model.WebhookRequest) (*model.NewWebhook, error) {
+	scoped, _, err := s.subs.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !s.policy.AllowPrivate {
+		if err = webhook.CheckURL(ctx, strings.TrimSpace(req.URL)); err != nil {
+			return nil, err
+		}
+	}
+
+	secret := strings.TrimSpace(req.Secret)
+	if secret == "" {
+		raw := make([]byte, webhookSecretBytes)
+		if _, err = rand.Read(raw); err != nil {
+			return nil, err
+		}
+		secret = webhookSecretPrefix + hex.EncodeToString(raw)
+	}
+
+	created := &model.NewWebhook{
+		Webhook: model.Webhook{
+			URL:    strings.TrimSpace(req.URL),
+			Events: slices.Compact(slices.Sorted(slices.Values(req.Events))),
+			Secret: secret,
+		},
+		Secret: secret,
+	}
+	err = scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		return repo.CreateWebhook(ctx, &created.Webhook)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *WebhookService) GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	var hook *model.Webhook
+	err := s.subs.read(ctx, func(repo sub.SubscriptionRepository) error {
+		var err error
+		hook, err = repo.GetWebhookByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (s *WebhookService) GetAll(ctx context.Context) ([]model.Webhook, error) {
+	var hooks []model.Webhook
+	err := s.subs.read(ctx, func(repo sub.SubscriptionRepository) error {
+		var err error
+		hooks, err = repo.GetWebhooks(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// Delete removes the webhook with its queued and dead deliveries.
+func (s *WebhookService) Delete(ctx context.Context, id uuid.UUID) error {
+	scoped, _, err := s.subs.scoped(ctx)
+	if err != nil {
+		return err
+	}
+	return scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		return repo.DeleteWebhook(ctx, id)
+	})
+}
+
+// DeadLetters returns the deliveries that ran out of attempts, oldest first.
+func (s *WebhookService) DeadLetters(ctx context.Context) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := s.subs.read(ctx, func(repo sub.SubscriptionRepository) error {
+		var err error
+		deliveries, err = repo.GetDeliveries(ctx, model.DeliveryDead)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues a dead delivery again with a fresh set of attempts, the dispatcher sends it
+// on its next run. Pending and delivered deliveries can't be redelivered.
+func (s *WebhookService) Redeliver(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	scoped, _, err := s.subs.scoped(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var delivery *model.WebhookDelivery
+	err = scoped.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+		var err error
+		if delivery, err = repo.GetDeliveryByID(ctx, id); err != nil {
+			return err
+		}
+		switch delivery.Status {
+		case model.DeliveryPending:
+			return ErrDeliveryPending
+		case model.DeliveryDelivered:
+			return ErrDeliveryDelivered
+		}
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt = model.DeliveryPending, 0, time.Now()
+		return repo.UpdateDelivery(ctx, delivery)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Dispatch sends the deliveries of every organization due at now and schedules the retries
+// of the failed ones. It returns the number of deliveries sent successfully.
+func (s *WebhookService) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	// Claimed deliveries are hidden from other instances until all of them could have timed out.
+	lease := s.policy.Timeout * (dispatchBatch + 1)
+	deliveries, err := s.subs.repo.ClaimDeliveries(ctx, now, lease, dispatchBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var sent int
+	var errs []error
+	for i := range deliveries {
+		// On shutdown the remaining deliveries are retried once the lease ends.
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		delivery := &deliveries[i]
+		hook, err := s.subs.repo.GetWebhookByID(ctx, delivery.WebhookID)
+		if err != nil {
+			// A deleted webhook took its deliveries along, others are retried once the lease ends.
+			if !errors.Is(err, sub.ErrNotFound) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		err = s.sender.Send(ctx, hook, delivery)
+		delivery.Attempts++
+		if err == nil {
+			delivered := time.Now()
+			delivery.Status, delivery.LastError, delivery.DeliveredAt = model.DeliveryDelivered, nil, &delivered
+			sent++
+		} else {
+			delivery.LastError = lastError(err)
+			if delivery.Attempts >= s.policy.MaxAttempts {
+				delivery.Status, delivery.NextAttemptAt = model.DeliveryDead, now
+			} else {
+				delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+			}
+		}
+
+		if err = s.subs.repo.UpdateDelivery(ctx, delivery); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// backoff returns the delay before the retry following the attempt.
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	return s.policy.Backoff << min(attempts-1, 20)
+}
+
+// EmitEndingSoon queues a subscription.ending_soon event for the subscriptions whose last month
+// is the month of now or the next one. The event ID derives from the subscription and its end,
+// so every end is only announced once however often it runs. It returns the number of deliveries queued.
+func (s *WebhookService) EmitEndingSoon(ctx context.Context, now time.Time) (int, error) {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var emitted int
+	var errs []error
+	for _, m := range []time.Time{month, month.AddDate(0, 1, 0)} {
+		subs, err := s.subs.repo.GetAll(ctx, model.ListFilter{EndsIn: period.Format(m)})
+		if err != nil {
+			return emitted, err
+		}
+		for i := range subs {
+			subscription := &subs[i]
+			id := uuid.NewSHA1(uuid.NameSpaceURL,
+				[]byte(model.EventSubscriptionEndingSoon+":"+subscription.ID.String()+":"+*subscription.EndDate))
+			event := newWebhookEvent(id, model.EventSubscriptionEndingSoon, subscription, now)
+
+			repo := s.subs.repo.ForOrganization(subscription.OrganizationID)
+			err := repo.WithTx(ctx, func(repo sub.SubscriptionRepository) error {
+				queued, err := publishEvent(ctx, repo, event)
+				emitted += queued
+				return err
+			})
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return emitted, errors.Join(errs...)
+}
+
+// publish queues the event of the subscription for the webhooks of its organization subscribed to it.
+// It runs in the transaction of the change, so that events are queued if and only if it's committed.
+func (s *SubService) publish(ctx context.Context, repo sub.SubscriptionRepository, eventType string, subscription *model.Subscription) error {
+	_, err := publishEvent(ctx, repo, newWebhookEvent(uuid.New(), eventType, subscription, time.Now()))
+	return err
+}
+
+func newWebhookEvent(id uuid.UUID, eventType string, subscription *model.Subscription, now time.Time) *model.WebhookEvent {
+	data := *subscription
+	setStatus(&data, now)
+	return &model.WebhookEvent{
+		ID:             id,
+		Type:           eventType,
+		OccurredAt:     now.UTC(),
+		OrganizationID: subscription.OrganizationID,
+		Data:           &data,
+	}
+}
+
+// publishEvent queues a delivery of the event for every subscribed webhook of its organization,
+// skipping the webhooks it's already queued for. It returns the number of deliveries queued.
+func publishEvent(ctx context.Context, repo sub.SubscriptionRepository, event *model.WebhookEvent) (int, error) {
+	hooks, err := repo.GetWebhooks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var queued int
+	var payload []byte
+	for _, hook := range hooks {
+		if hook.OrganizationID != event.OrganizationID || !slices.Contains(hook.Events, event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return queued, err
+			}
+		}
+
+		delivery := &model.WebhookDelivery{
+			WebhookID:      hook.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         model.DeliveryPending,
+			NextAttemptAt:  event.OccurredAt,
+			OrganizationID: hook.OrganizationID,
+		}
+		if err = repo.AddDelivery(ctx, delivery); err != nil {
+			if errors.Is(err, sub.ErrConflict) {
+				continue
+			}
+			return queued, err
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+func lastError(err error) *string {
+	msg := err.Error()
+	if len(msg) > maxLastErrorLength {
+		msg = msg[:maxLastErrorLength]
+	}
+	return &msg
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"subscription-service/internal/currency"
+	"subscription-service/internal/model"
+	"subscription-service/internal/repository/sub/memory"
+	"subscription-service/internal/webhook"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestWebhookDeliveryRetries(t *testing.T) {
+	ctx := context.Background()
+	var status, received atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer receiver.Close()
+
+	repo := memory.NewSubMemoryRepository()
+	subs := NewSubService(repo, currency.NewConverter(nil), Scope{}, OverlapAllow)
+	policy := DeliveryPolicy{MaxAttempts: 2, Backoff: time.Minute, Timeout: time.Second, AllowPrivate: true}
+	webhooks := NewWebhookService(subs, policy)
+
+	if _, err := webhooks.Create(ctx, model.WebhookRequest{URL: receiver.URL, Events: []string{model.EventSubscriptionCreated}}); err != nil {
+		t.Fatalf("Create webhook: %v", err)
+	}
+	subscription := &model.Subscription{ServiceName: "Netflix", Price: 100, UserID: uuid.New(), StartDate: "01-2025"}
+	if err := subs.Create(ctx, subscription); err != nil {
+		t.Fatalf("Create subscription: %v", err)
+	}
+
+	delivery := func(status string) model.WebhookDelivery {
+		t.Helper()
+		deliveries, err := repo.GetDeliveries(ctx, status)
+		if err != nil || len(deliveries) != 1 {
+			t.Fatalf("%s deliveries = %v, %v, want one", status, deliveries, err)
+		}
+		return deliveries[0]
+	}
+
+	// A 5xx response schedules a retry after the backoff.
+	now := time.Now()
+	if sent, err := webhooks.Dispatch(ctx, now); sent != 0 || err != nil {
+		t.Fatalf("Dispatch = %d, %v, want 0 sent", sent, err)
+	}
+	pending := delivery(model.DeliveryPending)
+	if pending.Attempts != 1 || !pending.NextAttemptAt.Equal(now.Add(policy.Backoff)) || pending.LastError == nil {
+		t.Fatalf("after a failure: attempts = %d, next attempt = %s, last error = %v, want 1, %s and an error",
+			pending.Attempts, pending.NextAttemptAt, pending.LastError, now.Add(policy.Backoff))
+	}
+	if _, err := webhooks.Dispatch(ctx, now.Add(policy.Backoff-time.Second)); err != nil || received.Load() != 1 {
+		t.Fatalf("retry before the backoff: %d requests, %v", received.Load(), err)
+	}
+
+	// The last attempt makes the delivery dead.
+	if _, err := webhooks.Dispatch(ctx, now.Add(policy.Backoff)); err != nil {
+		t.Fatalf("Dispatch retry: %v", err)
+	}
+	dead := delivery(model.DeliveryDead)
+	if dead.Attempts != policy.MaxAttempts {
+		t.Errorf("dead delivery attempts = %d, want %d", dead.Attempts, policy.MaxAttempts)
+	}
+	if letters, err := webhooks.DeadLetters(ctx); err != nil || len(letters) != 1 {
+		t.Errorf("DeadLetters = %v, %v, want the delivery", letters, err)
+	}
+	if _, err := webhooks.Dispatch(ctx, now.Add(time.Hour)); err != nil || received.Load() != 2 {
+		t.Fatalf("dead delivery sent: %d requests, %v", received.Load(), err)
+	}
+
+	// Redelivery queues it with all attempts for the dispatcher.
+	status.Store(http.StatusNoContent)
+	redelivered, err := webhooks.Redeliver(ctx, dead.ID)
+	if err != nil {
+		t.Fatalf("Redeliver: %v", err)
+	}
+	if redelivered.Status != model.DeliveryPending || redelivered.Attempts != 0 || received.Load() != 2 {
+		t.Errorf("redelivered: status = %s, attempts = %d, %d requests, want pending, 0 and not sent yet",
+			redelivered.Status, redelivered.Attempts, received.Load())
+	}
+	if _, err = webhooks.Redeliver(ctx, dead.ID); !errors.Is(err, ErrDeliveryPending) {
+		t.Errorf("Redeliver pending: err = %v, want %v", err, ErrDeliveryPending)
+	}
+	if sent, err := webhooks.Dispatch(ctx, time.Now()); sent != 1 || err != nil {
+		t.Fatalf("Dispatch redelivery = %d, %v, want 1 sent", sent, err)
+	}
+	if delivered := delivery(model.DeliveryDelivered); delivered.Attempts != 1 || delivered.DeliveredAt == nil {
+		t.Errorf("delivered: attempts = %d, delivered at = %v", delivered.Attempts, delivered.DeliveredAt)
+	}
+	if _, err = webhooks.Redeliver(ctx, dead.ID); !errors.Is(err, ErrDeliveryDelivered) {
+		t.Errorf("Redeliver delivered: err = %v, want %v", err, ErrDeliveryDelivered)
+	}
+}
+
+func TestCreateWebhookRejectsPrivateTarget(t *testing.T) {
+	subs := NewSubService(memory.NewSubMemoryRepository(), currency.NewConverter(nil), Scope{}, OverlapAllow)
+	webhooks := NewWebhookService(subs, DeliveryPolicy{MaxAttempts: 1, Backoff: time.Second, Timeout: time.Second})
+
+	_, err := webhooks.Create(context.Background(), model.WebhookRequest{
+		URL:    "http://169.254.169.254/latest/meta-data",
+		Events: []string{model.EventSubscriptionCreated},
+	})
+	if !errors.Is(err, webhook.ErrPrivateTarget) {
+		t.Fatalf("Create for the metadata endpoint: err = %v, want %v", err, webhook.ErrPrivateTarget)
+	}
+}
@@ -0,0 +1,166 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Client certificate policies.
+const (
+	ClientAuthNone    = ""
+	ClientAuthVerify  = "verify_if_given"
+	ClientAuthRequire = "require"
+)
+
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is the CA bundle client certificates are verified against.
+	ClientCAFile string
+	ClientAuth   string
+}
+
+// Reloader serves the certificate and client CA bundle from files and reloads them
+// when the files change, so certificates can be rotated without a restart.
+type Reloader struct {
+	cfg        Config
+	clientAuth tls.ClientAuthType
+	logger     *log.Logger
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+func NewReloader(cfg Config, logger *log.Logger) (*Reloader, error) {
+	r := &Reloader{cfg: cfg, logger: logger}
+
+	switch cfg.ClientAuth {
+	case ClientAuthNone:
+		r.clientAuth = tls.NoClientCert
+	case ClientAuthVerify:
+		r.clientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		r.clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unknown client auth %q", cfg.ClientAuth)
+	}
+	if r.clientAuth != tls.NoClientCert && cfg.ClientCAFile == "" {
+		return nil, errors.New("client certificate verification requires a CA file")
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig returns the server configuration using the current certificate and CA bundle.
+// The configuration of every connection offers HTTP/2, which net/http only adds to the returned one.
+func (r *Reloader) TLSConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	cfg := base.Clone()
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		clientCfg := base.Clone()
+		clientCfg.Certificates = []tls.Certificate{*r.cert}
+		clientCfg.ClientAuth = r.clientAuth
+		clientCfg.ClientCAs = r.clientCA
+		return clientCfg, nil
+	}
+	return cfg
+}
+
+// Watch checks the files every interval until ctx is done. A failed reload keeps
+// the previous certificate, so a half-written file doesn't break serving.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !r.changed() {
+			continue
+		}
+		if err := r.load(); err != nil {
+			r.logger.Println("TLS certificate reload failed:", err)
+			continue
+		}
+		r.logger.Println("TLS certificate reloaded")
+	}
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+func (r *Reloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, name := range r.files() {
+		info, err := os.Stat(name)
+		if err != nil {
+			r.logger.Println("TLS file check failed:", err)
+			return false
+		}
+		if !info.ModTime().Equal(r.modTimes[name]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, name := range r.files() {
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		modTimes[name] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+
+	var clientCA *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client CA: %w", err)
+		}
+		clientCA = x509.NewCertPool()
+		if !clientCA.AppendCertsFromPEM(pem) {
+			return errors.New("client CA file has no certificates")
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.clientCA, r.modTimes = &cert, clientCA, modTimes
+	r.mu.Unlock()
+	return nil
+}
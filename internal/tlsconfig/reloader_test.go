@@ -0,0 +1,144 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate with the serial number and its key.
+func writeCert(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serve completes the handshake of every connection to the listener with the config.
+func serve(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// handshake connects to the address and returns the connection state.
+func handshake(t *testing.T, addr string) tls.ConnectionState {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h2", "http/1.1"}})
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState()
+}
+
+func TestReloaderServesRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, 1)
+
+	reloader, err := NewReloader(Config{CertFile: certFile, KeyFile: keyFile}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("NewReloader: %v", err)
+	}
+	addr := serve(t, reloader.TLSConfig())
+
+	state := handshake(t, addr)
+	if serial := state.PeerCertificates[0].SerialNumber.Int64(); serial != 1 {
+		t.Fatalf("serial = %d, want 1", serial)
+	}
+	if state.NegotiatedProtocol != "h2" {
+		t.Errorf("negotiated protocol = %q, want h2", state.NegotiatedProtocol)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 10*time.Millisecond)
+
+	writeCert(t, certFile, keyFile, 2)
+	// The modification time may not change within the file system's resolution.
+	later := time.Now().Add(time.Second)
+	for _, name := range []string{certFile, keyFile} {
+		if err = os.Chtimes(name, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for handshake(t, addr).PeerCertificates[0].SerialNumber.Int64() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("rotated certificate not served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloaderKeepsCertificateOnFailedReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, 1)
+
+	reloader, err := NewReloader(Config{CertFile: certFile, KeyFile: keyFile}, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("NewReloader: %v", err)
+	}
+	if err = os.WriteFile(keyFile, []byte("half-written"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = reloader.load(); err == nil {
+		t.Fatal("load of an invalid key succeeded")
+	}
+
+	state := handshake(t, serve(t, reloader.TLSConfig()))
+	if serial := state.PeerCertificates[0].SerialNumber.Int64(); serial != 1 {
+		t.Errorf("serial = %d, want the previous certificate 1", serial)
+	}
+}
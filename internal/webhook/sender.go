@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"subscription-service/internal/model"
+	"time"
+)
+
+// Headers of webhook requests. The signature is the hex HMAC-SHA256 of "<timestamp>.<body>"
+// keyed with the webhook secret, prefixed with "sha256=".
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sender posts deliveries to webhooks.
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender returns a sender refusing to connect to addresses that aren't public, redirects included,
+// unless allowPrivate is set for receivers in the local network.
+func NewSender(timeout time.Duration, allowPrivate bool) *Sender {
+	if allowPrivate {
+		return &Sender{client: &http.Client{Timeout: timeout}}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Requests go to the receiver directly, so that the dialer checks its address rather than a proxy's.
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: timeout, Control: checkDial}).DialContext
+	return &Sender{client: &http.Client{Timeout: timeout, Transport: transport}}
+}
+
+// Send posts the delivery payload, any response other than 2xx is an error.
+func (s *Sender) Send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature receivers compare with the X-Webhook-Signature header.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
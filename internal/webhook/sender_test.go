@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"subscription-service/internal/model"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// verify checks the request the way receivers are told to: the HMAC-SHA256 of "<timestamp>.<body>"
+// compared in constant time.
+func verify(r *http.Request, secret string) bool {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(r.Header.Get(HeaderTimestamp) + "." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(r.Header.Get(HeaderSignature)), []byte(want))
+}
+
+func TestSendSignature(t *testing.T) {
+	const secret = "whsec_test"
+	delivery := &model.WebhookDelivery{
+		ID:        uuid.New(),
+		EventType: model.EventSubscriptionCreated,
+		Payload:   []byte(`{"type":"subscription.created"}`),
+	}
+
+	var verified bool
+	var headers http.Header
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		verified = verify(r, secret)
+	}))
+	defer receiver.Close()
+
+	sender := NewSender(time.Second, true)
+	if err := sender.Send(context.Background(), &model.Webhook{URL: receiver.URL, Secret: secret}, delivery); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if !verified {
+		t.Errorf("signature %q doesn't verify", headers.Get(HeaderSignature))
+	}
+	if headers.Get(HeaderEvent) != delivery.EventType || headers.Get(HeaderDelivery) != delivery.ID.String() {
+		t.Errorf("event headers = %q, %q", headers.Get(HeaderEvent), headers.Get(HeaderDelivery))
+	}
+	if ts, err := strconv.ParseInt(headers.Get(HeaderTimestamp), 10, 64); err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+		t.Errorf("timestamp = %q", headers.Get(HeaderTimestamp))
+	}
+
+	if err := sender.Send(context.Background(), &model.Webhook{URL: receiver.URL, Secret: "other"}, delivery); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if verified {
+		t.Error("signature with another secret verifies")
+	}
+}
+
+func TestSendRefusesPrivateAddress(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the loopback receiver")
+	}))
+	defer receiver.Close()
+
+	err := NewSender(time.Second, false).Send(context.Background(), &model.Webhook{URL: receiver.URL}, &model.WebhookDelivery{})
+	if !errors.Is(err, ErrPrivateTarget) {
+		t.Fatalf("Send to a loopback address: err = %v, want %v", err, ErrPrivateTarget)
+	}
+}
@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// ErrPrivateTarget rejects webhook URLs resolving to addresses of the service's own network,
+// which would let callers reach internal services and cloud metadata endpoints.
+var ErrPrivateTarget = errors.New("webhook URL must resolve to a public address")
+
+// sharedAddressSpace is the carrier-grade NAT range, private to the provider network.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// IsPublic tells whether webhooks may be sent to the address: it must not be loopback, private,
+// link-local, unspecified or multicast.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() &&
+		!addr.IsUnspecified() && !addr.IsMulticast() && !sharedAddressSpace.Contains(addr)
+}
+
+// CheckURL resolves the host of the URL and returns ErrPrivateTarget
+// unless all of its addresses are public.
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPrivateTarget, err)
+	}
+	for _, addr := range addrs {
+		if !IsPublic(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrPrivateTarget, u.Hostname(), addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// checkDial refuses connections to addresses that aren't public. It runs after resolving,
+// so a host changing its address after CheckURL is caught as well.
+func checkDial(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublic(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateTarget, addrPort.Addr().Unmap())
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"203.0.113.10", true},
+		{"2001:db8::1", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"100.64.0.1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("IsPublic(%s) = %t, want %t", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	ctx := context.Background()
+	if err := CheckURL(ctx, "https://203.0.113.10/hook"); err != nil {
+		t.Errorf("public address: %v", err)
+	}
+	for _, rawURL := range []string{"http://169.254.169.254/latest/meta-data", "http://127.0.0.1:8080/hook", "http://[::1]/hook", "http://localhost/hook"} {
+		if err := CheckURL(ctx, rawURL); !errors.Is(err, ErrPrivateTarget) {
+			t.Errorf("CheckURL(%s) = %v, want %v", rawURL, err, ErrPrivateTarget)
+		}
+	}
+}
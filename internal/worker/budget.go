@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"subscription-service/internal/service"
+	"time"
+)
+
+// BudgetEvaluator periodically compares the budgets with the spend of the current month and sends alerts.
+type BudgetEvaluator struct {
+	srv      *service.BudgetService
+	interval time.Duration
+	logger   *log.Logger
+}
+
+func NewBudgetEvaluator(srv *service.BudgetService, interval time.Duration, logger *log.Logger) *BudgetEvaluator {
+	return &BudgetEvaluator{srv: srv, interval: interval, logger: logger}
+}
+
+func (e *BudgetEvaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := e.srv.Evaluate(ctx, time.Now())
+		if err != nil {
+			e.logger.Println("Budget evaluation failed:", err)
+		}
+		if n > 0 {
+			e.logger.Printf("Sent %d budget alerts", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
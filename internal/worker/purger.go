@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"subscription-service/internal/service"
+	"time"
+)
+
+// Purger periodically hard-deletes subscriptions soft-deleted longer than the retention period.
+type Purger struct {
+	srv       *service.SubService
+	interval  time.Duration
+	retention time.Duration
+	logger    *log.Logger
+}
+
+func NewPurger(srv *service.SubService, interval, retention time.Duration, logger *log.Logger) *Purger {
+	return &Purger{srv: srv, interval: interval, retention: retention, logger: logger}
+}
+
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := p.srv.PurgeDeleted(ctx, p.retention); err != nil {
+			p.logger.Println("Purge of deleted subscriptions failed:", err)
+		} else if n > 0 {
+			p.logger.Printf("Purged %d subscriptions deleted more than %s ago", n, p.retention)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"subscription-service/internal/service"
+	"time"
+)
+
+// endingSoonInterval is how often subscriptions ending soon are looked for,
+// each one is announced once anyway.
+const endingSoonInterval = time.Hour
+
+// WebhookDispatcher periodically sends the queued webhook deliveries and queues the events
+// of subscriptions ending soon.
+type WebhookDispatcher struct {
+	srv      *service.WebhookService
+	interval time.Duration
+	logger   *log.Logger
+}
+
+func NewWebhookDispatcher(srv *service.WebhookService, interval time.Duration, logger *log.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{srv: srv, interval: interval, logger: logger}
+}
+
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	var scanned time.Time
+	for {
+		now := time.Now()
+		if now.Sub(scanned) >= endingSoonInterval {
+			n, err := d.srv.EmitEndingSoon(ctx, now)
+			if err != nil {
+				d.logger.Println("Queueing subscriptions ending soon failed:", err)
+			} else {
+				scanned = now
+			}
+			if n > 0 {
+				d.logger.Printf("Queued %d deliveries of subscriptions ending soon", n)
+			}
+		}
+
+		n, err := d.srv.Dispatch(ctx, now)
+		if err != nil {
+			d.logger.Println("Webhook dispatch failed:", err)
+		}
+		if n > 0 {
+			d.logger.Printf("Delivered %d webhook events", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
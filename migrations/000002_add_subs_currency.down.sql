@@ -0,0 +1 @@
+ALTER TABLE subs DROP COLUMN IF EXISTS currency
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE subs ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'RUB'
\ No newline at end of file
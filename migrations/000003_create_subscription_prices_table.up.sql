@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS subscription_prices (
+    id UUID PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES subs (id) ON DELETE CASCADE,
+    price INT NOT NULL,
+    effective_from VARCHAR(7) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (subscription_id, effective_from)
+)
\ No newline at end of file
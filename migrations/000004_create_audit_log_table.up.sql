@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    operation VARCHAR(16) NOT NULL,
+    request_id VARCHAR(255),
+    before JSONB,
+    after JSONB,
+    diff JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_subscription_id_idx ON audit_log (subscription_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor, created_at);
\ No newline at end of file
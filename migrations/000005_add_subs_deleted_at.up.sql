@@ -0,0 +1,3 @@
+ALTER TABLE subs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS subs_deleted_at_idx ON subs (deleted_at) WHERE deleted_at IS NOT NULL;
\ No newline at end of file
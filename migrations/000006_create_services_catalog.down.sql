@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS subs_service_id_idx;
+
+ALTER TABLE subs DROP COLUMN IF EXISTS service_id;
+
+DROP TABLE IF EXISTS service_aliases;
+
+DROP TABLE IF EXISTS services;
\ No newline at end of file
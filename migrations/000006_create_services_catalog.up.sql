@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS services (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    category VARCHAR(255),
+    default_price INT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS service_aliases (
+    alias VARCHAR(255) PRIMARY KEY,
+    service_id UUID NOT NULL REFERENCES services (id) ON DELETE CASCADE
+);
+
+INSERT INTO services (id, name)
+SELECT gen_random_uuid(), MIN(TRIM(service_name))
+FROM subs
+GROUP BY LOWER(REGEXP_REPLACE(TRIM(service_name), '\s+', ' ', 'g'));
+
+INSERT INTO service_aliases (alias, service_id)
+SELECT LOWER(REGEXP_REPLACE(TRIM(name), '\s+', ' ', 'g')), id
+FROM services
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE subs ADD COLUMN IF NOT EXISTS service_id UUID REFERENCES services (id);
+
+UPDATE subs
+SET service_id = a.service_id, service_name = s.name
+FROM service_aliases a
+JOIN services s ON s.id = a.service_id
+WHERE a.alias = LOWER(REGEXP_REPLACE(TRIM(subs.service_name), '\s+', ' ', 'g'));
+
+ALTER TABLE subs ALTER COLUMN service_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS subs_service_id_idx ON subs (service_id);
\ No newline at end of file
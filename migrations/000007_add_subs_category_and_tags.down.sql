@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS subscription_tags;
+
+DROP TABLE IF EXISTS tags;
+
+DROP INDEX IF EXISTS subs_category_idx;
+
+ALTER TABLE subs DROP COLUMN IF EXISTS category;
\ No newline at end of file
@@ -0,0 +1,18 @@
+ALTER TABLE subs ADD COLUMN IF NOT EXISTS category VARCHAR(255);
+
+UPDATE subs SET category = s.category FROM services s WHERE s.id = subs.service_id;
+
+CREATE INDEX IF NOT EXISTS subs_category_idx ON subs (category);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS subscription_tags (
+    subscription_id UUID NOT NULL REFERENCES subs (id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (subscription_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS subscription_tags_tag_id_idx ON subscription_tags (tag_id);
\ No newline at end of file
@@ -0,0 +1,21 @@
+DROP POLICY IF EXISTS subscription_prices_organization_isolation ON subscription_prices;
+ALTER TABLE subscription_prices NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_prices DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS audit_log_organization_isolation ON audit_log;
+ALTER TABLE audit_log NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE audit_log DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS subs_organization_isolation ON subs;
+ALTER TABLE subs NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subs DISABLE ROW LEVEL SECURITY;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS organization_id;
+
+DROP INDEX IF EXISTS audit_log_organization_id_idx;
+
+ALTER TABLE audit_log DROP COLUMN IF EXISTS organization_id;
+
+DROP INDEX IF EXISTS subs_organization_id_idx;
+
+ALTER TABLE subs DROP COLUMN IF EXISTS organization_id;
\ No newline at end of file
@@ -0,0 +1,28 @@
+ALTER TABLE subs ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+
+CREATE INDEX IF NOT EXISTS subs_organization_id_idx ON subs (organization_id, user_id);
+
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+
+CREATE INDEX IF NOT EXISTS audit_log_organization_id_idx ON audit_log (organization_id, created_at);
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS organization_id UUID;
+
+-- Row-level security only restricts sessions that set app.organization_id,
+-- which the service does in tenant scoped transactions.
+ALTER TABLE subs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subs FORCE ROW LEVEL SECURITY;
+CREATE POLICY subs_organization_isolation ON subs
+    USING (NULLIF(current_setting('app.organization_id', true), '') IS NULL
+        OR organization_id = NULLIF(current_setting('app.organization_id', true), '')::UUID);
+
+ALTER TABLE audit_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_log FORCE ROW LEVEL SECURITY;
+CREATE POLICY audit_log_organization_isolation ON audit_log
+    USING (NULLIF(current_setting('app.organization_id', true), '') IS NULL
+        OR organization_id = NULLIF(current_setting('app.organization_id', true), '')::UUID);
+
+ALTER TABLE subscription_prices ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_prices FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_prices_organization_isolation ON subscription_prices
+    USING (EXISTS (SELECT 1 FROM subs WHERE subs.id = subscription_id));
\ No newline at end of file
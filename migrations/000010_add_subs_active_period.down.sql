@@ -0,0 +1,5 @@
+ALTER TABLE subs DROP CONSTRAINT IF EXISTS subs_no_overlap;
+
+DROP INDEX IF EXISTS subs_active_period_idx;
+
+ALTER TABLE subs DROP COLUMN IF EXISTS active_period;
\ No newline at end of file
@@ -0,0 +1,30 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- A range can't end before it starts. Subscriptions stored with such an end must be fixed by hand
+-- before migrating, the migration fails listing them rather than guessing their period.
+DO $$
+DECLARE
+    bad_ids TEXT;
+BEGIN
+    SELECT STRING_AGG(id::TEXT || ' (' || start_date || ' to ' || end_date || ')', ', ' ORDER BY id) INTO bad_ids
+    FROM subs
+    WHERE end_date IS NOT NULL
+        AND SUBSTRING(end_date, 4, 4) || SUBSTRING(end_date, 1, 2) < SUBSTRING(start_date, 4, 4) || SUBSTRING(start_date, 1, 2);
+
+    IF bad_ids IS NOT NULL THEN
+        RAISE EXCEPTION 'subscriptions end before they start, fix their end_date and migrate again: %', bad_ids;
+    END IF;
+END
+$$;
+
+-- The period of a subscription as a range of the first days of its months, unbounded without end date.
+ALTER TABLE subs ADD COLUMN IF NOT EXISTS active_period DATERANGE GENERATED ALWAYS AS (
+    DATERANGE(
+        MAKE_DATE(SUBSTRING(start_date, 4, 4)::INT, SUBSTRING(start_date, 1, 2)::INT, 1),
+        MAKE_DATE(SUBSTRING(end_date, 4, 4)::INT, SUBSTRING(end_date, 1, 2)::INT, 1),
+        '[]'
+    )
+) STORED;
+
+CREATE INDEX IF NOT EXISTS subs_active_period_idx ON subs USING gist (user_id, service_id, active_period)
+    WHERE deleted_at IS NULL;
\ No newline at end of file
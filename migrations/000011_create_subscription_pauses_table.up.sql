@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+    id UUID PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES subs (id) ON DELETE CASCADE,
+    start_date VARCHAR(7) NOT NULL,
+    end_date VARCHAR(7),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS subscription_pauses_subscription_id_idx ON subscription_pauses (subscription_id);
+
+ALTER TABLE subscription_pauses ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_pauses FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_pauses_organization_isolation ON subscription_pauses
+    USING (EXISTS (SELECT 1 FROM subs WHERE subs.id = subscription_id));
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS subscription_promos;
+ALTER TABLE subs DROP COLUMN IF EXISTS trial_end;
\ No newline at end of file
@@ -0,0 +1,16 @@
+ALTER TABLE subs ADD COLUMN IF NOT EXISTS trial_end VARCHAR(7);
+
+CREATE INDEX IF NOT EXISTS subs_trial_end_idx ON subs (trial_end);
+
+CREATE TABLE IF NOT EXISTS subscription_promos (
+    subscription_id UUID NOT NULL REFERENCES subs (id) ON DELETE CASCADE,
+    price INT NOT NULL CHECK (price >= 0),
+    start_date VARCHAR(7) NOT NULL,
+    end_date VARCHAR(7) NOT NULL,
+    PRIMARY KEY (subscription_id, start_date)
+);
+
+ALTER TABLE subscription_promos ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_promos FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_promos_organization_isolation ON subscription_promos
+    USING (EXISTS (SELECT 1 FROM subs WHERE subs.id = subscription_id));
\ No newline at end of file
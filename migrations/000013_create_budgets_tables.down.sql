@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS budget_alerts;
+
+DROP TABLE IF EXISTS budgets;
\ No newline at end of file
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS budgets (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    user_id UUID,
+    category VARCHAR(255),
+    amount INT NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    thresholds INT[] NOT NULL,
+    organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS budgets_organization_id_idx ON budgets (organization_id, user_id);
+
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    id UUID PRIMARY KEY,
+    budget_id UUID NOT NULL REFERENCES budgets (id) ON DELETE CASCADE,
+    month VARCHAR(7) NOT NULL,
+    threshold INT NOT NULL,
+    amount INT NOT NULL,
+    spent INT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (budget_id, month, threshold)
+);
+
+ALTER TABLE budgets ENABLE ROW LEVEL SECURITY;
+ALTER TABLE budgets FORCE ROW LEVEL SECURITY;
+CREATE POLICY budgets_organization_isolation ON budgets
+    USING (NULLIF(current_setting('app.organization_id', true), '') IS NULL
+        OR organization_id = NULLIF(current_setting('app.organization_id', true), '')::UUID);
+
+ALTER TABLE budget_alerts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE budget_alerts FORCE ROW LEVEL SECURITY;
+CREATE POLICY budget_alerts_organization_isolation ON budget_alerts
+    USING (EXISTS (SELECT 1 FROM budgets WHERE budgets.id = budget_id));
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+
+DROP TABLE IF EXISTS webhooks;
+
+DROP INDEX IF EXISTS subs_end_date_idx;
\ No newline at end of file
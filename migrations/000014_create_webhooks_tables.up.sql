@@ -0,0 +1,42 @@
+CREATE INDEX IF NOT EXISTS subs_end_date_idx ON subs (end_date);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_organization_id_idx ON webhooks (organization_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error TEXT,
+    organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ,
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (status, next_attempt_at);
+
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhooks FORCE ROW LEVEL SECURITY;
+CREATE POLICY webhooks_organization_isolation ON webhooks
+    USING (NULLIF(current_setting('app.organization_id', true), '') IS NULL
+        OR organization_id = NULLIF(current_setting('app.organization_id', true), '')::UUID);
+
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries FORCE ROW LEVEL SECURITY;
+CREATE POLICY webhook_deliveries_organization_isolation ON webhook_deliveries
+    USING (NULLIF(current_setting('app.organization_id', true), '') IS NULL
+        OR organization_id = NULLIF(current_setting('app.organization_id', true), '')::UUID);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS audit_log_user_id_idx;
+
+ALTER TABLE audit_log DROP COLUMN IF EXISTS user_id;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS user_id UUID;
+
+-- Entries written before the column have the current owner, the previous ones are unknown.
+UPDATE audit_log SET user_id = subs.user_id FROM subs WHERE subs.id = audit_log.subscription_id AND audit_log.user_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS audit_log_user_id_idx ON audit_log (user_id, created_at);
\ No newline at end of file
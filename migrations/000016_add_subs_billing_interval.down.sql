@@ -0,0 +1 @@
+ALTER TABLE subs DROP COLUMN IF EXISTS billing_interval;
@@ -0,0 +1,3 @@
+-- The number of months between charges, every month unless set.
+ALTER TABLE subs ADD COLUMN IF NOT EXISTS billing_interval INT NOT NULL DEFAULT 1
+    CHECK (billing_interval BETWEEN 1 AND 12);
@@ -0,0 +1,16 @@
+package period
+
+import (
+	"time"
+)
+
+// Layout is the month format used for subscription dates (MM-YYYY).
+const Layout = "01-2006"
+
+func Parse(month string) (time.Time, error) {
+	return time.Parse(Layout, month)
+}
+
+func Format(month time.Time) string {
+	return month.Format(Layout)
+}
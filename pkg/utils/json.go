@@ -29,3 +29,25 @@ func WriteJSON(w http.ResponseWriter, statusCode int, data any) error {
 	w.WriteHeader(statusCode)
 	return json.NewEncoder(w).Encode(data)
 }
+
+// Problem is an RFC 7807 problem details response.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func WriteProblem(w http.ResponseWriter, statusCode int, detail string) {
+	response := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("JSON encode error: %v", err)
+	}
+}
@@ -1,24 +1,57 @@
 package validator
 
 import (
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"subscription-service/internal/auth"
 	"subscription-service/internal/model"
+	"subscription-service/pkg/period"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	maxCategoryLength = 255
+	maxTagLength      = 64
+	// maxBillingInterval allows charging up to once a year.
+	maxBillingInterval = 12
+	// maxBudgetThreshold allows alerts on spend up to ten times the budget.
+	maxBudgetThreshold = 1000
+)
+
 func ValidateSubRequest(req model.SubRequest) []string {
 	var errors []string
 
-	if req.ServiceName == "" {
-		errors = append(errors, "name is required")
+	if req.ServiceID == uuid.Nil && strings.TrimSpace(req.ServiceName) == "" {
+		errors = append(errors, "service_id or service_name is required")
 	}
 
-	if req.Price <= 0 {
+	if req.Price < 0 {
 		errors = append(errors, "price must be positive")
 	}
 
+	if req.Currency != "" && !ValidateCurrency(req.Currency) {
+		errors = append(errors, "currency must be a 3-letter ISO 4217 code")
+	}
+
+	if req.BillingInterval < 0 || req.BillingInterval > maxBillingInterval {
+		errors = append(errors, "billing_interval must be between 1 and 12 months")
+	}
+
+	if req.Category != nil && len(*req.Category) > maxCategoryLength {
+		errors = append(errors, "category is too long")
+	}
+
+	for _, tag := range req.Tags {
+		if !ValidateTag(tag) {
+			errors = append(errors, "tags must be non-empty and at most 64 characters long")
+			break
+		}
+	}
+
 	if req.UserID == uuid.Nil {
 		errors = append(errors, "user_id is required")
 	}
@@ -32,6 +65,230 @@ func ValidateSubRequest(req model.SubRequest) []string {
 	if req.EndDate != nil && *req.EndDate != "" {
 		if !ValidateMonthYear(*req.EndDate) {
 			errors = append(errors, "end_date has invalid format, must be 'MM-YYYY'")
+		} else if ValidateMonthYear(req.StartDate) {
+			start, _ := period.Parse(req.StartDate)
+			end, _ := period.Parse(*req.EndDate)
+			if end.Before(start) {
+				errors = append(errors, "end_date must not be before start_date")
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return validateTrialAndPromos(req)
+}
+
+// validateTrialAndPromos checks that the trial and promos lie within the subscription period
+// and that the promos don't overlap, the period must be valid.
+func validateTrialAndPromos(req model.SubRequest) []string {
+	var errors []string
+
+	start, _ := period.Parse(req.StartDate)
+	var end time.Time
+	if req.EndDate != nil && *req.EndDate != "" {
+		end, _ = period.Parse(*req.EndDate)
+	}
+	within := func(month time.Time) bool {
+		return !month.Before(start) && (end.IsZero() || !month.After(end))
+	}
+
+	if req.TrialEnd != nil && *req.TrialEnd != "" {
+		if !ValidateMonthYear(*req.TrialEnd) {
+			errors = append(errors, "trial_end has invalid format, must be 'MM-YYYY'")
+		} else if trialEnd, _ := period.Parse(*req.TrialEnd); !within(trialEnd) {
+			errors = append(errors, "trial_end must be within the subscription period")
+		}
+	}
+
+	ranges := make([][2]time.Time, 0, len(req.Promos))
+	for _, promo := range req.Promos {
+		if promo.Price < 0 {
+			errors = append(errors, "promo price must not be negative")
+			break
+		}
+		if !ValidateMonthYear(promo.StartDate) || !ValidateMonthYear(promo.EndDate) {
+			errors = append(errors, "promo start_date and end_date are required, must be 'MM-YYYY'")
+			break
+		}
+		from, _ := period.Parse(promo.StartDate)
+		until, _ := period.Parse(promo.EndDate)
+		if until.Before(from) {
+			errors = append(errors, "promo end_date must not be before its start_date")
+			break
+		}
+		if !within(from) || !within(until) {
+			errors = append(errors, "promos must be within the subscription period")
+			break
+		}
+		ranges = append(ranges, [2]time.Time{from, until})
+	}
+
+	slices.SortFunc(ranges, func(a, b [2]time.Time) int { return a[0].Compare(b[0]) })
+	for i := 1; i < len(ranges); i++ {
+		if !ranges[i][0].After(ranges[i-1][1]) {
+			errors = append(errors, "promos must not overlap")
+			break
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+func ValidateServiceRequest(req model.ServiceRequest) []string {
+	var errors []string
+
+	if strings.TrimSpace(req.Name) == "" {
+		errors = append(errors, "name is required")
+	}
+
+	if req.DefaultPrice != nil && *req.DefaultPrice <= 0 {
+		errors = append(errors, "default_price must be positive")
+	}
+
+	for _, alias := range req.Aliases {
+		if strings.TrimSpace(alias) == "" {
+			errors = append(errors, "aliases must not be empty")
+			break
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+func ValidateAPIKeyRequest(req model.APIKeyRequest) []string {
+	var errors []string
+
+	if strings.TrimSpace(req.Name) == "" {
+		errors = append(errors, "name is required")
+	}
+
+	if len(req.Scopes) == 0 {
+		errors = append(errors, "scopes are required")
+	}
+	for _, scope := range req.Scopes {
+		if scope != auth.RoleAdmin && scope != auth.RoleUser && scope != auth.RoleAuditor {
+			errors = append(errors, "scopes must be one of 'admin', 'user' or 'auditor'")
+			break
+		}
+	}
+
+	if req.UserID != nil && *req.UserID == uuid.Nil {
+		errors = append(errors, "user_id must not be empty")
+	}
+
+	if req.OrganizationID != nil && *req.OrganizationID == uuid.Nil {
+		errors = append(errors, "organization_id must not be empty")
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		errors = append(errors, "expires_at must be in the future")
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+func ValidateBudgetRequest(req model.BudgetRequest) []string {
+	var errors []string
+
+	if strings.TrimSpace(req.Name) == "" {
+		errors = append(errors, "name is required")
+	}
+
+	if req.Amount <= 0 {
+		errors = append(errors, "amount must be positive")
+	}
+
+	if req.Currency != "" && !ValidateCurrency(req.Currency) {
+		errors = append(errors, "currency must be a 3-letter ISO 4217 code")
+	}
+
+	if req.UserID != nil && *req.UserID == uuid.Nil {
+		errors = append(errors, "user_id must not be empty")
+	}
+
+	if req.Category != nil && len(*req.Category) > maxCategoryLength {
+		errors = append(errors, "category is too long")
+	}
+
+	for _, threshold := range req.Thresholds {
+		if threshold <= 0 || threshold > maxBudgetThreshold {
+			errors = append(errors, "thresholds must be percents from 1 to 1000")
+			break
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+func ValidateWebhookRequest(req model.WebhookRequest) []string {
+	var errors []string
+
+	if u, err := url.Parse(strings.TrimSpace(req.URL)); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errors = append(errors, "url must be an absolute http or https URL")
+	}
+
+	if len(req.Events) == 0 {
+		errors = append(errors, "events are required")
+	}
+	for _, event := range req.Events {
+		if !slices.Contains(model.WebhookEvents, event) {
+			errors = append(errors, "events must be one of "+strings.Join(model.WebhookEvents, ", "))
+			break
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+func ValidatePriceChangeRequest(req model.PriceChangeRequest) []string {
+	var errors []string
+
+	if req.Price <= 0 {
+		errors = append(errors, "price must be positive")
+	}
+
+	if req.EffectiveFrom == "" {
+		errors = append(errors, "effective_from is required")
+	} else if !ValidateMonthYear(req.EffectiveFrom) {
+		errors = append(errors, "effective_from has invalid format, must be 'MM-YYYY'")
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+func ValidatePauseRequest(req model.PauseRequest) []string {
+	errors := ValidateLifecycleRequest(model.LifecycleRequest{EffectiveFrom: req.EffectiveFrom})
+
+	if req.Until != nil {
+		if !ValidateMonthYear(*req.Until) {
+			errors = append(errors, "until has invalid format, must be 'MM-YYYY'")
 		}
 	}
 
@@ -42,6 +299,22 @@ func ValidateSubRequest(req model.SubRequest) []string {
 	return nil
 }
 
+func ValidateLifecycleRequest(req model.LifecycleRequest) []string {
+	var errors []string
+
+	if req.EffectiveFrom == "" {
+		errors = append(errors, "effective_from is required")
+	} else if !ValidateMonthYear(req.EffectiveFrom) {
+		errors = append(errors, "effective_from has invalid format, must be 'MM-YYYY'")
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
 func ValidateMonthYear(dateStr string) bool {
 	parts := strings.Split(dateStr, "-")
 	if len(parts) != 2 {
@@ -74,3 +347,20 @@ func ValidateMonthYear(dateStr string) bool {
 
 	return true
 }
+
+func ValidateCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+func ValidateTag(tag string) bool {
+	tag = strings.TrimSpace(tag)
+	return tag != "" && len(tag) <= maxTagLength
+}